@@ -1,59 +1,384 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/config"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/health"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/jobs"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/limits"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/logging"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/login"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/mail"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/metrics"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/persisted"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/ratelimit"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/server"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/status"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
+	}
+
+	slog.SetDefault(logging.New(os.Stdout, logging.ParseLevel(cfg.LogLevel)))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingOTLPEndpoint, cfg.TracingSampleRatio)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
 	}
 
 	// Connect to database
-	db, err := database.New(cfg.DatabaseDSN())
+	db, err := database.New(cfg.DatabaseDSN(), cfg.SlowQueryThreshold)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
-	defer db.Close()
 
-	log.Println("✅ Connected to database successfully")
+	slog.Info("Connected to database")
+
+	if cfg.CheckDBVersion {
+		if err := models.NewServerConfigRepository(db).CheckDBVersion(context.Background()); err != nil {
+			fatal("Database check failed", err)
+		}
+	}
+
+	if err := db.Migrate(context.Background()); err != nil {
+		fatal("Failed to migrate database", err)
+	}
 
 	// Create GraphQL resolver
 	resolver := graph.NewResolver(db)
 
+	nameRules := models.DefaultNameRules()
+	nameRules.BlockedWords = cfg.NameBlockedWords
+	nameRules.HistoryCooldown = cfg.NameHistoryCooldown
+	resolver.NameValidator = models.NewNameValidator(db, nameRules)
+
+	creation := models.DefaultCharacterCreation()
+	creation.MaxPerAccount = cfg.MaxCharactersPerAccount
+	if cfg.CharacterTemplatesFile != "" {
+		creation.Templates, err = models.LoadCharacterTemplates(cfg.CharacterTemplatesFile)
+		if err != nil {
+			fatal("Failed to load character templates", err)
+		}
+	}
+	resolver.PlayerRepository.SetCharacterCreation(creation)
+	resolver.DeletionGracePeriod = cfg.CharacterDeletionGrace
+	resolver.ModeratorPlayerID = cfg.ModeratorPlayerID
+	resolver.AccountStorageRepository.SetVipLimits(models.VipLimits{
+		Free:    cfg.VipFreeLimit,
+		Premium: cfg.VipPremiumLimit,
+	})
+	resolver.AccountSessionRepository.SetTTL(cfg.SessionTTL)
+	resolver.AccountRecoveryRepository.SetLimit(models.RecoveryLimit{
+		MaxAttempts:        cfg.RecoveryMaxAttempts,
+		MaxAccountAttempts: cfg.RecoveryMaxAccountAttempts,
+		Window:             cfg.RecoveryWindow,
+	})
+
+	statusClient := status.NewClient(cfg.StatusAddr)
+	statusClient.Timeout = cfg.StatusTimeout
+	resolver.StatusMonitor = status.NewMonitor(statusClient, cfg.StatusCacheTTL)
+
+	if cfg.SMTPHost != "" {
+		sender := &mail.SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+		resolver.AccountTokenRepository.SetMailer(sender, models.AccountMail{
+			ResetURL:  cfg.PasswordResetURL,
+			VerifyURL: cfg.EmailVerifyURL,
+			ResetTTL:  cfg.PasswordResetTTL,
+			VerifyTTL: cfg.EmailVerifyTTL,
+		})
+	} else {
+		slog.Warn("SMTP_HOST is not set; password resets and email verification are disabled")
+	}
+
+	if cfg.ServerDataDir != "" {
+		xmlDir := filepath.Join(cfg.ServerDataDir, "XML")
+		resolver.QuestCatalog, err = models.LoadQuestCatalog(filepath.Join(xmlDir, "quests.xml"))
+		if err != nil {
+			fatal("Failed to load quests", err)
+		}
+		resolver.OutfitCatalog, err = models.LoadOutfitCatalog(filepath.Join(xmlDir, "outfits.xml"))
+		if err != nil {
+			fatal("Failed to load outfits", err)
+		}
+		resolver.MountCatalog, err = models.LoadMountCatalog(filepath.Join(xmlDir, "mounts.xml"))
+		if err != nil {
+			fatal("Failed to load mounts", err)
+		}
+	}
+
+	// Background jobs
+	runner := jobs.NewRunner()
+	runner.Add(jobs.Job{
+		Name:     "character-deletion",
+		Interval: cfg.CharacterDeletionInterval,
+		Run: func(ctx context.Context) error {
+			deleted, err := resolver.PlayerRepository.DeleteExpired(ctx)
+			if deleted > 0 {
+				slog.InfoContext(ctx, "Deleted characters past their deletion date", "count", deleted)
+			}
+			return err
+		},
+	})
+	runner.Add(jobs.Job{
+		Name:     "account-token-cleanup",
+		Interval: cfg.TokenCleanupInterval,
+		Run: func(ctx context.Context) error {
+			_, err := resolver.AccountSessionRepository.DeleteExpired(ctx)
+			return err
+		},
+	})
+	runner.Add(jobs.Job{
+		Name:     "online-history",
+		Interval: cfg.OnlineSampleInterval,
+		Run: func(ctx context.Context) error {
+			// players_online outlives a crashed server, so ask the server
+			// itself and count an unreachable one as empty.
+			online, err := resolver.StatusMonitor.PlayersOnline(ctx)
+			if err != nil {
+				slog.WarnContext(ctx, "Game server status check failed", "error", err)
+			}
+
+			if err := resolver.OnlineHistoryRepository.Record(ctx, online); err != nil {
+				return err
+			}
+			_, err = resolver.OnlineHistoryRepository.DeleteBefore(ctx, time.Now().Add(-cfg.OnlineHistoryRetention).Unix())
+			return err
+		},
+	})
+	runner.Start(context.Background())
+
 	// Create GraphQL server
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  resolver,
+		Complexity: graph.NewComplexity(),
+	}))
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	if cfg.IntrospectionEnabled {
+		srv.Use(extension.Introspection{})
+	}
+
+	// Persisted queries: only operations from the manifest in allowlist
+	// mode, otherwise any operation, cached by hash for clients that send
+	// one
+	var operationNames []string
+	if cfg.AllowlistFile != "" {
+		manifest, err := persisted.LoadManifest(cfg.AllowlistFile)
+		if err != nil {
+			fatal("Failed to load allowlist", err)
+		}
+		srv.Use(persisted.Allowlist{Manifest: manifest})
+		operationNames = manifest.OperationNames()
+		slog.Info("Allowlist mode enabled", "operations", manifest.Len())
+	} else if cfg.APQCacheSize > 0 {
+		srv.Use(extension.AutomaticPersistedQuery{Cache: lru.New[string](cfg.APQCacheSize)})
+	}
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.SetRecoverFunc(graph.RecoverFunc)
+
+	// Throttle clients, then reject deep and costly operations before they
+	// run
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		rateLimits := ratelimit.DefaultLimits()
+		if err := ratelimit.ParseLimits(rateLimits, cfg.RateLimits); err != nil {
+			fatal("Invalid RATE_LIMITS", err)
+		}
+		limiter = ratelimit.New(ratelimit.NewMemoryStore(), rateLimits)
+		srv.Use(limiter)
+	}
+	srv.Use(limits.Depth{Max: cfg.MaxQueryDepth})
+	srv.Use(limits.Budget{
+		Anonymous: cfg.ComplexityAnonymous,
+		Account:   cfg.ComplexityAccount,
+		Staff:     cfg.ComplexityStaff,
+	}.Complexity())
+
+	// Prometheus metrics
+	appMetrics := metrics.New()
+	appMetrics.AddOperations(operationNames...)
+	appMetrics.AddOperations(cfg.MetricsOperations...)
+	appMetrics.Register(
+		metrics.DBStats(db.DB.DB, cfg.DBName),
+		&metrics.Game{
+			PlayersOnline: func(ctx context.Context) (int, error) {
+				online, _ := resolver.StatusMonitor.PlayersOnline(ctx)
+				return online, nil
+			},
+			MarketOffers: resolver.MarketRepository.CountOffers,
+		},
+	)
+	srv.Use(appMetrics)
+	srv.Use(tracing.Tracer{})
+
+	// Tibia 11+ client login
+	pvpType, err := login.ParsePvpType(cfg.LoginWorldType)
+	if err != nil {
+		fatal("Invalid LOGIN_WORLD_TYPE", err)
+	}
+	loginHandler := login.NewHandler(
+		resolver.AccountRepository,
+		resolver.PlayerRepository,
+		resolver.AccountBanRepository,
+		[]login.World{{
+			Name:     cfg.LoginWorldName,
+			Host:     cfg.LoginWorldHost,
+			Port:     cfg.LoginWorldPort,
+			Location: cfg.LoginWorldLocation,
+			PvpType:  pvpType,
+		}},
+	)
+	loginHandler.Limiter = limiter
 
 	// Setup Chi router
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	trustedProxies, err := auth.ParseProxies(cfg.TrustedProxies)
+	if err != nil {
+		fatal("Invalid TRUSTED_PROXIES", err)
+	}
+	r.Use(auth.TrustProxies(trustedProxies))
+	r.Use(tracing.Middleware)
+	r.Use(auth.Middleware(cfg.StaffAPIKeys, resolver.AccountSessionRepository))
+	r.Use(logging.Middleware)
+	r.Use(middleware.Recoverer)
 
 	// GraphQL routes
-	r.Handle("/", playground.Handler("GraphQL Playground", "/query"))
+	if cfg.PlaygroundEnabled {
+		r.Handle("/", playground.Handler("GraphQL Playground", "/query"))
+	}
 	r.Handle("/query", srv)
+	r.Post("/login.php", loginHandler.ServeHTTP)
+	if cfg.MetricsAddr == "" {
+		r.With(auth.StaffOnly).Handle("/metrics", appMetrics.Handler())
+	}
+
+	// Health probes skip the router so they aren't logged or traced
+	checks := []health.Check{{Name: "database", Run: db.PingContext}}
+	if cfg.CheckDBVersion {
+		checks = append(checks, health.Check{
+			Name: "db_version",
+			Run:  models.NewServerConfigRepository(db).CheckDBVersion,
+		})
+	}
+	readiness := health.NewReadiness(checks...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Liveness)
+	mux.Handle("GET /readyz", readiness)
+	mux.Handle("/", r)
 
 	// Start server
 	addr := fmt.Sprintf(":%s", cfg.ServerPort)
-	log.Printf("🚀 Server ready at http://localhost%s", addr)
-	log.Printf("📊 GraphQL Playground at http://localhost%s/", addr)
+	httpServer := server.New(addr, mux, server.Timeouts{
+		Read:  cfg.ServerReadTimeout,
+		Write: cfg.ServerWriteTimeout,
+		Idle:  cfg.ServerIdleTimeout,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatalf("Server error: %v", err)
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	// Metrics on their own address are left open, for a network only
+	// Prometheus can reach
+	var metricsServer *server.Server
+	if cfg.MetricsAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", appMetrics.Handler())
+		metricsServer = server.New(cfg.MetricsAddr, metricsMux, server.Timeouts{
+			Read:  cfg.ServerReadTimeout,
+			Write: cfg.ServerWriteTimeout,
+			Idle:  cfg.ServerIdleTimeout,
+		})
+		go func() {
+			serveErr <- metricsServer.ListenAndServe()
+		}()
+		slog.Info("Metrics ready", "addr", cfg.MetricsAddr)
+	}
+	if cfg.PlaygroundEnabled {
+		slog.Info("Server ready", "url", fmt.Sprintf("http://localhost%s", addr), "playground", fmt.Sprintf("http://localhost%s/", addr))
+	} else {
+		slog.Info("Server ready", "url", fmt.Sprintf("http://localhost%s", addr))
+	}
+
+	select {
+	case err := <-serveErr:
+		fatal("Server error", err)
+	case <-ctx.Done():
+	}
+
+	// Report draining and keep serving until load balancers notice, then
+	// drain requests and subscriptions and stop the jobs before closing the
+	// database they use
+	slog.Info("Shutting down", "drain_delay", cfg.ServerDrainDelay.String(), "timeout", cfg.ServerShutdownTimeout.String())
+	readiness.Drain()
+	time.Sleep(cfg.ServerDrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ServerShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Server did not shut down cleanly", "error", err)
+	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Metrics server did not shut down cleanly", "error", err)
+		}
+	}
+	runner.Stop()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
 	}
+	if err := db.Close(); err != nil {
+		slog.Error("Failed to close database", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrForbidden       = errors.New("forbidden: staff access required")
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrNotOwner        = errors.New("forbidden: the account belongs to someone else")
+)
+
+// Principal is the caller a request was authenticated as.
+type Principal struct {
+	Staff  bool
+	APIKey string
+
+	// Name identifies the staff member in audit logs
+	Name string
+
+	// AccountID and SessionToken are set for requests made with an account
+	// session token
+	AccountID    int
+	SessionToken string
+}
+
+// SessionStore resolves account session tokens. Authenticate returns 0 for
+// unknown or expired tokens.
+type SessionStore interface {
+	Authenticate(ctx context.Context, token string) (int, error)
+}
+
+type contextKey struct{}
+
+type clientIPKey struct{}
+
+// ClientIP returns the address a request came from, or "" outside a request.
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the authenticated principal, or nil for anonymous
+// requests.
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// IsStaff reports whether the request was made with a staff API key.
+func IsStaff(ctx context.Context) bool {
+	principal := FromContext(ctx)
+	return principal != nil && principal.Staff
+}
+
+// RequireStaff returns ErrForbidden unless the request was made by staff.
+func RequireStaff(ctx context.Context) error {
+	if !IsStaff(ctx) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// RequireAccount returns the account the request was made for, or
+// ErrUnauthenticated without an account session.
+func RequireAccount(ctx context.Context) (int, error) {
+	principal := FromContext(ctx)
+	if principal == nil || principal.AccountID == 0 {
+		return 0, ErrUnauthenticated
+	}
+	return principal.AccountID, nil
+}
+
+// RequireOwner returns nil for staff and for sessions of accountID. Other
+// account sessions get ErrNotOwner, anonymous requests ErrUnauthenticated.
+func RequireOwner(ctx context.Context, accountID int) error {
+	if IsStaff(ctx) {
+		return nil
+	}
+	sessionAccountID, err := RequireAccount(ctx)
+	if err != nil {
+		return err
+	}
+	if sessionAccountID != accountID {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// Middleware authenticates requests carrying "Authorization: Bearer <key>"
+// against the configured staff API keys. Keys may be given as "name:key" to
+// name the staff member using them; unnamed keys act as "staff". Other
+// tokens are looked up as account sessions when sessions is set. Requests
+// without a valid token are passed through anonymously. The client address
+// is recorded for ClientIP.
+func Middleware(staffKeys []string, sessions SessionStore) func(http.Handler) http.Handler {
+	keys := make(map[string]string, len(staffKeys))
+	for _, entry := range staffKeys {
+		name, key, ok := strings.Cut(entry, ":")
+		if !ok {
+			name, key = "staff", entry
+		}
+		keys[key] = name
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			r = r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip))
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if name, staff := keys[token]; staff {
+				r = r.WithContext(WithPrincipal(r.Context(), &Principal{Staff: true, APIKey: token, Name: name}))
+			} else if sessions != nil {
+				accountID, err := sessions.Authenticate(r.Context(), token)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "Failed to check session", "error", err)
+					http.Error(w, "failed to check session", http.StatusInternalServerError)
+					return
+				}
+				if accountID != 0 {
+					r = r.WithContext(WithPrincipal(r.Context(), &Principal{AccountID: accountID, SessionToken: token}))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaffOnly answers 403 Forbidden to requests Middleware didn't
+// authenticate as staff.
+func StaffOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsStaff(r.Context()) {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var staff bool
+	handler := Middleware([]string{"secret-key"}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		staff = IsStaff(r.Context())
+	}))
+
+	tests := []struct {
+		header string
+		staff  bool
+	}{
+		{"Bearer secret-key", true},
+		{"Bearer wrong-key", false},
+		{"secret-key", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, tt.staff, staff, tt.header)
+	}
+}
+
+func TestMiddleware_NamedKeys(t *testing.T) {
+	var principal *Principal
+	handler := Middleware([]string{"alice:alice-key", "shared-key"}, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		key  string
+		name string
+	}{
+		{"alice-key", "alice"},
+		{"shared-key", "staff"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/query", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.key)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if assert.NotNil(t, principal, tt.key) {
+			assert.Equal(t, tt.name, principal.Name)
+		}
+	}
+}
+
+type fakeSessions map[string]int
+
+func (s fakeSessions) Authenticate(ctx context.Context, token string) (int, error) {
+	return s[token], nil
+}
+
+func TestMiddleware_Sessions(t *testing.T) {
+	var accountID int
+	var accountErr error
+	handler := Middleware([]string{"secret-key"}, fakeSessions{"session-token": 7})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accountID, accountErr = RequireAccount(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.Header.Set("Authorization", "Bearer session-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.NoError(t, accountErr)
+	assert.Equal(t, 7, accountID)
+
+	req.Header.Set("Authorization", "Bearer expired-token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.ErrorIs(t, accountErr, ErrUnauthenticated)
+}
+
+func TestMiddleware_ClientIP(t *testing.T) {
+	var ip string
+	handler := Middleware(nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = ClientIP(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "203.0.113.7", ip)
+}
+
+func TestRequireStaff(t *testing.T) {
+	assert.ErrorIs(t, RequireStaff(context.Background()), ErrForbidden)
+
+	ctx := WithPrincipal(context.Background(), &Principal{Staff: true})
+	assert.NoError(t, RequireStaff(ctx))
+}
+
+func TestRequireOwner(t *testing.T) {
+	assert.ErrorIs(t, RequireOwner(context.Background(), 1), ErrUnauthenticated)
+
+	ctx := WithPrincipal(context.Background(), &Principal{AccountID: 2})
+	assert.ErrorIs(t, RequireOwner(ctx, 1), ErrNotOwner)
+	assert.NoError(t, RequireOwner(ctx, 2))
+
+	ctx = WithPrincipal(context.Background(), &Principal{Staff: true})
+	assert.NoError(t, RequireOwner(ctx, 1))
+}
+
+func TestStaffOnly(t *testing.T) {
+	handler := Middleware([]string{"secret"}, nil)(StaffOnly(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseProxies parses trusted proxy addresses and CIDR ranges, such as
+// "10.0.0.0/8" or "127.0.0.1".
+func ParseProxies(entries []string) ([]netip.Prefix, error) {
+	proxies := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// TrustProxies sets a request's remote address to the client address in
+// X-Forwarded-For when the request comes from one of proxies. The header is
+// read from the right, skipping proxies, so the client address is the one
+// the nearest untrusted hop was seen from; clients can't choose it by
+// sending the header themselves. It must run before Middleware, which
+// records the address for ClientIP.
+func TrustProxies(proxies []netip.Prefix) func(http.Handler) http.Handler {
+	trusted := func(addr netip.Addr) bool {
+		for _, prefix := range proxies {
+			if prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(proxies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			peer, err := netip.ParseAddr(host)
+			if err != nil || !trusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := peer
+			hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = hop
+				if !trusted(hop) {
+					break
+				}
+			}
+
+			r2 := r.Clone(r.Context())
+			r2.RemoteAddr = client.Unmap().String()
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxies(t *testing.T) {
+	proxies, err := ParseProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	require.NoError(t, err)
+	assert.Len(t, proxies, 3)
+
+	_, err = ParseProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
+
+func TestTrustProxies(t *testing.T) {
+	proxies, err := ParseProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	var ip string
+	handler := TrustProxies(proxies)(Middleware(nil, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = ClientIP(r.Context())
+	})))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"Direct", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"UntrustedPeerIgnoresHeader", "203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"Proxied", "10.0.0.2:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"SpoofedHeader", "10.0.0.2:443", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"ProxyChain", "10.0.0.2:443", []string{"198.51.100.1", "10.0.0.3"}, "198.51.100.1"},
+		{"NoHeader", "10.0.0.2:443", nil, "10.0.0.2"},
+		{"InvalidHop", "10.0.0.2:443", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/query", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, ip)
+		})
+	}
+}
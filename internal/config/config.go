@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,6 +17,119 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+
+	// HTTP connection timeouts, and how long shutdown waits for requests and
+	// subscriptions to finish
+	ServerReadTimeout     time.Duration
+	ServerWriteTimeout    time.Duration
+	ServerIdleTimeout     time.Duration
+	ServerShutdownTimeout time.Duration
+	// How long shutdown keeps serving after /readyz reports draining, so
+	// load balancers stop sending traffic first
+	ServerDrainDelay time.Duration
+
+	// GraphQL limits: the deepest nesting allowed and the complexity budget
+	// per operation for each kind of caller; 0 turns a limit off
+	MaxQueryDepth       int
+	ComplexityAnonymous int
+	ComplexityAccount   int
+	ComplexityStaff     int
+
+	// GraphQL endpoints: introspection and the playground can be turned off,
+	// and an allowlist manifest restricts /query to the operations in it
+	IntrospectionEnabled bool
+	PlaygroundEnabled    bool
+	APQCacheSize         int
+	AllowlistFile        string
+
+	// Rate limiting; entries in RateLimits ("createAccount=3/1h") override
+	// the built-in limits
+	RateLimitEnabled bool
+	RateLimits       []string
+	// Proxies whose X-Forwarded-For header gives the client address
+	TrustedProxies []string
+
+	// Bearer tokens that identify staff requests
+	StaffAPIKeys []string
+	// Player recorded as the author of IP bans and namelocks
+	ModeratorPlayerID int
+
+	// The game server's data directory, for its XML definitions
+	ServerDataDir string
+
+	// Character names containing any of these words are rejected
+	NameBlockedWords []string
+	// How long a name given up in a rename stays reserved
+	NameHistoryCooldown time.Duration
+
+	// Character creation
+	CharacterTemplatesFile  string
+	MaxCharactersPerAccount int
+
+	// Character deletion
+	CharacterDeletionGrace    time.Duration
+	CharacterDeletionInterval time.Duration
+
+	// VIP list size for free and premium accounts
+	VipFreeLimit    int
+	VipPremiumLimit int
+
+	// Account sessions and emails
+	SessionTTL           time.Duration
+	PasswordResetTTL     time.Duration
+	PasswordResetURL     string
+	EmailVerifyTTL       time.Duration
+	EmailVerifyURL       string
+	TokenCleanupInterval time.Duration
+
+	// Failed account recoveries allowed per address and per account name in
+	// a window
+	RecoveryMaxAttempts        int
+	RecoveryMaxAccountAttempts int
+	RecoveryWindow             time.Duration
+
+	// Outgoing mail; account emails are disabled without SMTPHost
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// The world listed to Tibia 11+ clients by login.php
+	LoginWorldName     string
+	LoginWorldHost     string
+	LoginWorldPort     int
+	LoginWorldLocation string
+	LoginWorldType     string
+
+	// The game server's status port, probed for serverStatus
+	StatusAddr     string
+	StatusTimeout  time.Duration
+	StatusCacheTTL time.Duration
+
+	// Refuse to start on a TFS schema the API wasn't written for
+	CheckDBVersion bool
+
+	// Online player count sampling for onlineHistory
+	OnlineSampleInterval   time.Duration
+	OnlineHistoryRetention time.Duration
+
+	// Metrics: a separate address to serve /metrics on, instead of serving
+	// it to staff on the API port, and the operation names given their own
+	// label besides those in the allowlist
+	MetricsAddr       string
+	MetricsOperations []string
+
+	// Logging; statements slower than SlowQueryThreshold are logged, 0 turns
+	// that off
+	LogLevel           string
+	SlowQueryThreshold time.Duration
+
+	// Tracing: "otlp", "stdout" or empty for off; the endpoint is the OTLP
+	// collector's host:port
+	TracingExporter     string
+	TracingOTLPEndpoint string
+	TracingSampleRatio  float64
 }
 
 func Load() (*Config, error) {
@@ -29,6 +145,84 @@ func Load() (*Config, error) {
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "tfs"),
 		ServerPort: getEnv("SERVER_PORT", "8090"),
+
+		ServerReadTimeout:     getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerWriteTimeout:    getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		ServerIdleTimeout:     getEnvDuration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+		ServerShutdownTimeout: getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+		ServerDrainDelay:      getEnvDuration("SERVER_DRAIN_DELAY", 5*time.Second),
+
+		MaxQueryDepth:       getEnvInt("GRAPHQL_MAX_DEPTH", 12),
+		ComplexityAnonymous: getEnvInt("GRAPHQL_COMPLEXITY_ANONYMOUS", 2000),
+		ComplexityAccount:   getEnvInt("GRAPHQL_COMPLEXITY_ACCOUNT", 5000),
+		ComplexityStaff:     getEnvInt("GRAPHQL_COMPLEXITY_STAFF", 50000),
+
+		IntrospectionEnabled: getEnvBool("GRAPHQL_INTROSPECTION", true),
+		PlaygroundEnabled:    getEnvBool("GRAPHQL_PLAYGROUND", true),
+		APQCacheSize:         getEnvInt("GRAPHQL_APQ_CACHE_SIZE", 1000),
+		AllowlistFile:        getEnv("GRAPHQL_ALLOWLIST_FILE", ""),
+
+		RateLimitEnabled: getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimits:       getEnvList("RATE_LIMITS"),
+		TrustedProxies:   getEnvList("TRUSTED_PROXIES"),
+
+		StaffAPIKeys:      getEnvList("STAFF_API_KEYS"),
+		ModeratorPlayerID: getEnvInt("MODERATOR_PLAYER_ID", 0),
+		ServerDataDir:     getEnv("SERVER_DATA_DIR", ""),
+
+		NameBlockedWords:    getEnvList("NAME_BLOCKED_WORDS"),
+		NameHistoryCooldown: getEnvDuration("NAME_HISTORY_COOLDOWN", 30*24*time.Hour),
+
+		CharacterTemplatesFile:  getEnv("CHARACTER_TEMPLATES_FILE", ""),
+		MaxCharactersPerAccount: getEnvInt("MAX_CHARACTERS_PER_ACCOUNT", 10),
+
+		CharacterDeletionGrace:    getEnvDuration("CHARACTER_DELETION_GRACE", 30*24*time.Hour),
+		CharacterDeletionInterval: getEnvDuration("CHARACTER_DELETION_INTERVAL", time.Hour),
+
+		VipFreeLimit:    getEnvInt("VIP_FREE_LIMIT", 20),
+		VipPremiumLimit: getEnvInt("VIP_PREMIUM_LIMIT", 100),
+
+		SessionTTL:           getEnvDuration("SESSION_TTL", 24*time.Hour),
+		PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordResetURL:     getEnv("PASSWORD_RESET_URL", ""),
+		EmailVerifyTTL:       getEnvDuration("EMAIL_VERIFY_TTL", 48*time.Hour),
+		EmailVerifyURL:       getEnv("EMAIL_VERIFY_URL", ""),
+		TokenCleanupInterval: getEnvDuration("TOKEN_CLEANUP_INTERVAL", time.Hour),
+
+		RecoveryMaxAttempts:        getEnvInt("RECOVERY_MAX_ATTEMPTS", 5),
+		RecoveryMaxAccountAttempts: getEnvInt("RECOVERY_MAX_ACCOUNT_ATTEMPTS", 20),
+		RecoveryWindow:             getEnvDuration("RECOVERY_WINDOW", time.Hour),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", ""),
+
+		LoginWorldName:     getEnv("LOGIN_WORLD_NAME", "Forgotten"),
+		LoginWorldHost:     getEnv("LOGIN_WORLD_HOST", "127.0.0.1"),
+		LoginWorldPort:     getEnvInt("LOGIN_WORLD_PORT", 7172),
+		LoginWorldLocation: getEnv("LOGIN_WORLD_LOCATION", "USA"),
+		LoginWorldType:     getEnv("LOGIN_WORLD_TYPE", "pvp"),
+
+		StatusAddr:     getEnv("STATUS_ADDR", "127.0.0.1:7171"),
+		StatusTimeout:  getEnvDuration("STATUS_TIMEOUT", 3*time.Second),
+		StatusCacheTTL: getEnvDuration("STATUS_CACHE_TTL", 30*time.Second),
+
+		CheckDBVersion: getEnvBool("CHECK_DB_VERSION", true),
+
+		OnlineSampleInterval:   getEnvDuration("ONLINE_SAMPLE_INTERVAL", 5*time.Minute),
+		OnlineHistoryRetention: getEnvDuration("ONLINE_HISTORY_RETENTION", 90*24*time.Hour),
+
+		MetricsAddr:       getEnv("METRICS_ADDR", ""),
+		MetricsOperations: getEnvList("METRICS_OPERATIONS"),
+
+		LogLevel:           getEnv("LOG_LEVEL", "info"),
+		SlowQueryThreshold: getEnvDuration("SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+
+		TracingExporter:     getEnv("TRACING_EXPORTER", ""),
+		TracingOTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
+		TracingSampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
 	}
 
 	return cfg, nil
@@ -50,3 +244,42 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
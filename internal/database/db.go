@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -11,11 +14,20 @@ type DB struct {
 	*sqlx.DB
 }
 
-func New(dsn string) (*DB, error) {
-	db, err := sqlx.Connect("mysql", dsn)
+// New connects to MySQL. Statements are traced when the caller's context
+// carries a span, and those taking slowQuery or longer are logged, unless
+// slowQuery is 0.
+func New(dsn string, slowQuery time.Duration) (*DB, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database DSN: %w", err)
+	}
+	connector, err := mysql.NewConnector(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	instrumented := &instrumentedConnector{Connector: connector, slow: slowLog{threshold: slowQuery}}
+	db := sqlx.NewDb(sql.OpenDB(instrumented), "mysql")
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
@@ -32,3 +44,23 @@ func New(dsn string) (*DB, error) {
 func (db *DB) Close() error {
 	return db.DB.Close()
 }
+
+// Transaction runs fn inside a transaction, committing if fn returns nil and
+// rolling back otherwise.
+func (db *DB) Transaction(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Tables owned by the API (rather than by TFS) are created by the SQL files
+// in migrations/, applied in file name order and recorded in
+// api_schema_migrations so each one runs only once.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate applies any migrations that haven't been applied yet.
+func (db *DB) Migrate(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS api_schema_migrations (
+		version VARCHAR(255) NOT NULL PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`
+	if _, err := db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var applied []string
+	if err := db.SelectContext(ctx, &applied, `SELECT version FROM api_schema_migrations`); err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	done := make(map[string]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
+
+	for _, version := range versions {
+		if done[version] {
+			continue
+		}
+
+		data, err := migrationFiles.ReadFile("migrations/" + version + ".sql")
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", version, err)
+		}
+
+		err = db.Transaction(ctx, func(tx *sqlx.Tx) error {
+			for _, statement := range splitStatements(string(data)) {
+				if _, err := tx.ExecContext(ctx, statement); err != nil {
+					return err
+				}
+			}
+			query := `INSERT INTO api_schema_migrations (version, applied_at) VALUES (?, UNIX_TIMESTAMP())`
+			_, err := tx.ExecContext(ctx, query, version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// migrationVersions returns the embedded migration names in apply order.
+func migrationVersions() ([]string, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	versions := make([]string, len(names))
+	for i, name := range names {
+		versions[i] = strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+	}
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+// splitStatements splits a migration file on semicolons that end a line.
+func splitStatements(data string) []string {
+	var statements []string
+	for _, statement := range strings.Split(data, ";\n") {
+		statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+		if statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
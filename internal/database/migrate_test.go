@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return &DB{sqlx.NewDb(mockDB, "sqlmock")}, mock
+}
+
+func TestMigrate_AppliesPendingMigrations(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	versions, err := migrationVersions()
+	require.NoError(t, err)
+	require.NotEmpty(t, versions)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS api_schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM api_schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+
+	for _, version := range versions {
+		data, err := migrationFiles.ReadFile("migrations/" + version + ".sql")
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		for range splitStatements(string(data)) {
+			mock.ExpectExec(".+").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectExec("INSERT INTO api_schema_migrations").
+			WithArgs(version).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	require.NoError(t, db.Migrate(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrate_SkipsAppliedMigrations(t *testing.T) {
+	db, mock := newMockDB(t)
+	defer db.Close()
+
+	versions, err := migrationVersions()
+	require.NoError(t, err)
+
+	rows := sqlmock.NewRows([]string{"version"})
+	for _, version := range versions {
+		rows.AddRow(version)
+	}
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS api_schema_migrations").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM api_schema_migrations").
+		WillReturnRows(rows)
+
+	require.NoError(t, db.Migrate(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements("CREATE TABLE a (id INT);\n\nCREATE TABLE b (id INT);\n")
+
+	assert.Equal(t, []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}, statements)
+}
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_player_name_history (
+  id INT NOT NULL AUTO_INCREMENT,
+  player_id INT NOT NULL,
+  old_name VARCHAR(255) NOT NULL,
+  new_name VARCHAR(255) NOT NULL,
+  changed_at BIGINT NOT NULL,
+  PRIMARY KEY (id),
+  KEY player_id (player_id),
+  KEY old_name (old_name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_account_audit (
+  id INT NOT NULL AUTO_INCREMENT,
+  account_id INT NOT NULL,
+  action VARCHAR(32) NOT NULL,
+  old_value BIGINT NOT NULL,
+  new_value BIGINT NOT NULL,
+  actor VARCHAR(255) NOT NULL,
+  reason VARCHAR(255) NOT NULL,
+  created_at BIGINT NOT NULL,
+  PRIMARY KEY (id),
+  KEY account_id (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS api_account_sessions (
+  token_hash CHAR(64) NOT NULL,
+  account_id INT NOT NULL,
+  created_at BIGINT NOT NULL,
+  expires_at BIGINT NOT NULL,
+  PRIMARY KEY (token_hash),
+  KEY account_id (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS api_account_tokens (
+  token_hash CHAR(64) NOT NULL,
+  account_id INT NOT NULL,
+  purpose VARCHAR(16) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  created_at BIGINT NOT NULL,
+  expires_at BIGINT NOT NULL,
+  used_at BIGINT DEFAULT NULL,
+  PRIMARY KEY (token_hash),
+  KEY account_id (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS api_account_recovery_keys (
+  account_id INT NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  created_at BIGINT NOT NULL,
+  PRIMARY KEY (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE IF NOT EXISTS api_account_recovery_attempts (
+  id INT NOT NULL AUTO_INCREMENT,
+  account_name VARCHAR(32) NOT NULL,
+  ip VARCHAR(45) NOT NULL,
+  success TINYINT(1) NOT NULL,
+  attempted_at BIGINT NOT NULL,
+  PRIMARY KEY (id),
+  KEY account_name (account_name),
+  KEY ip (ip)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS api_account_preferences (
+  account_id INT NOT NULL,
+  hide_characters TINYINT(1) NOT NULL DEFAULT 0,
+  PRIMARY KEY (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS api_online_history (
+  id INT NOT NULL AUTO_INCREMENT,
+  sampled_at BIGINT NOT NULL,
+  players_online INT NOT NULL,
+  PRIMARY KEY (id),
+  KEY sampled_at (sampled_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_account_verified_emails (
+  account_id INT NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  verified_at BIGINT NOT NULL,
+  PRIMARY KEY (account_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+INSERT IGNORE INTO api_account_verified_emails (account_id, email, verified_at)
+SELECT t.account_id, t.email, MAX(t.used_at)
+FROM api_account_tokens t
+INNER JOIN accounts a ON a.id = t.account_id AND a.email = t.email
+WHERE t.purpose = 'EMAIL_VERIFY' AND t.used_at IS NOT NULL
+GROUP BY t.account_id, t.email;
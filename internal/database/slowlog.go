@@ -0,0 +1,24 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// slowLog logs statements that take at least threshold. Only the number of
+// arguments is logged, never their values, since they include passwords and
+// tokens.
+type slowLog struct {
+	threshold time.Duration
+}
+
+func (l slowLog) observe(ctx context.Context, query string, args int, elapsed time.Duration) {
+	if l.threshold > 0 && elapsed >= l.threshold {
+		slog.WarnContext(ctx, "Slow query",
+			"query", compactQuery(query),
+			"args", args,
+			"duration_ms", elapsed.Milliseconds(),
+		)
+	}
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConn runs every statement through prepared statements, like the
+// MySQL driver does for queries with arguments, taking delay each time.
+type fakeConn struct {
+	delay time.Duration
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *fakeConn) Commit() error                             { return nil }
+func (c *fakeConn) Rollback() error                           { return nil }
+
+type fakeStmt struct {
+	conn *fakeConn
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	time.Sleep(s.conn.delay)
+	return driver.RowsAffected(1), nil
+}
+
+type fakeConnector struct {
+	conn *fakeConn
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	logs := captureLogs(t)
+
+	conn := &fakeConn{}
+	db := sql.OpenDB(&instrumentedConnector{Connector: fakeConnector{conn}, slow: slowLog{threshold: 20 * time.Millisecond}})
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE accounts SET password = ? WHERE id = ?", "hunter2", 1)
+	require.NoError(t, err)
+	assert.Empty(t, logs.String(), "fast statements are not logged")
+
+	conn.delay = 30 * time.Millisecond
+	_, err = db.Exec("UPDATE accounts\n\t  SET password = ?  WHERE id = ?", "hunter2", 1)
+	require.NoError(t, err)
+
+	assert.NotContains(t, logs.String(), "hunter2")
+	require.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("\n")), "one record per slow statement")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "Slow query", record["msg"])
+	assert.Equal(t, "UPDATE accounts SET password = ? WHERE id = ?", record["query"])
+	assert.Equal(t, float64(2), record["args"])
+	assert.GreaterOrEqual(t, record["duration_ms"], float64(30))
+}
+
+func TestSlowQueryLog_Transaction(t *testing.T) {
+	logs := captureLogs(t)
+
+	db := &DB{sqlx.NewDb(sql.OpenDB(&instrumentedConnector{
+		Connector: fakeConnector{&fakeConn{delay: 10 * time.Millisecond}},
+		slow:      slowLog{threshold: time.Millisecond},
+	}), "mysql")}
+	defer db.Close()
+
+	err := db.Transaction(context.Background(), func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("DELETE FROM api_account_sessions WHERE account_id = ?", 1)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "DELETE FROM api_account_sessions")
+}
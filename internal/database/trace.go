@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+
+// traceStatement records a span for a statement that ran from start to end.
+// Statements outside a traced request, such as background jobs, are skipped
+// rather than each starting a trace of their own.
+func traceStatement(ctx context.Context, query string, start, end time.Time, err error) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return
+	}
+
+	operation, table := statementName(query)
+	name := operation
+	attrs := []attribute.KeyValue{semconv.DBSystemNameMySQL, semconv.DBOperationName(operation), semconv.DBQueryText(compactQuery(query))}
+	if table != "" {
+		name += " " + table
+		attrs = append(attrs, semconv.DBCollectionName(table))
+	}
+
+	_, span := otel.Tracer(instrumentation).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// statementName returns a statement's operation and the table it works on,
+// e.g. "SELECT" and "players", for naming its span.
+func statementName(query string) (string, string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+	var after string
+	switch operation {
+	case "SELECT", "DELETE":
+		after = "FROM"
+	case "INSERT", "REPLACE":
+		after = "INTO"
+	case "UPDATE":
+		after = "UPDATE"
+	default:
+		return operation, ""
+	}
+
+	for i, field := range fields[:len(fields)-1] {
+		if strings.EqualFold(field, after) {
+			return operation, strings.Trim(fields[i+1], "`(),;")
+		}
+	}
+	return operation, ""
+}
+
+// compactQuery collapses a statement's whitespace onto one line.
+func compactQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
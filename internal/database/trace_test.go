@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStatementName(t *testing.T) {
+	tests := []struct {
+		query     string
+		operation string
+		table     string
+	}{
+		{"SELECT id, name FROM players WHERE id = ?", "SELECT", "players"},
+		{"select count(*)\n\t\tfrom `players_online`", "SELECT", "players_online"},
+		{"INSERT INTO api_online_history (sampled_at, players) VALUES (?, ?)", "INSERT", "api_online_history"},
+		{"UPDATE accounts SET password = ? WHERE id = ?", "UPDATE", "accounts"},
+		{"DELETE FROM api_account_sessions WHERE expires_at < ?", "DELETE", "api_account_sessions"},
+		{"SELECT UNIX_TIMESTAMP()", "SELECT", ""},
+		{"START TRANSACTION", "START", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		operation, table := statementName(tt.query)
+		assert.Equal(t, tt.operation, operation, tt.query)
+		assert.Equal(t, tt.table, table, tt.query)
+	}
+}
+
+func TestTraceStatement(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := sql.OpenDB(&instrumentedConnector{Connector: fakeConnector{&fakeConn{}}})
+	defer db.Close()
+
+	// Without a span in the context nothing is traced.
+	_, err := db.Exec("UPDATE accounts SET password = ? WHERE id = ?", "x", 1)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	_, err = db.ExecContext(ctx, "UPDATE accounts\n\tSET password = ? WHERE id = ?", "x", 1)
+	require.NoError(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "UPDATE accounts", span.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Contains(t, span.Attributes(), attribute.String("db.query.text", "UPDATE accounts SET password = ? WHERE id = ?"))
+	assert.Contains(t, span.Attributes(), attribute.String("db.collection.name", "accounts"))
+	assert.Contains(t, span.Attributes(), attribute.String("db.system.name", "mysql"))
+}
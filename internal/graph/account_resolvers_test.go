@@ -0,0 +1,241 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/mail"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutationResolver_AddVipEntry(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT premium_ends_at > UNIX_TIMESTAMP\\(\\) FROM accounts").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"premium"}).AddRow(false))
+	mock.ExpectQuery("SELECT player_id FROM account_viplist").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id"}))
+	mock.ExpectExec("INSERT INTO account_viplist").
+		WithArgs(1, 2, "Friend", 0, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	entry, err := resolver.Mutation().AddVipEntry(accountContext(1), models.VipEntryInput{
+		AccountID: 1, PlayerID: 2, Description: "Friend",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, entry.PlayerID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_UpdateVipEntry_OtherAccount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	entry, err := resolver.Mutation().UpdateVipEntry(accountContext(2), models.VipEntryInput{
+		AccountID: 1, PlayerID: 2, Description: "Friend",
+	})
+
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, entry)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_RemoveVipEntry_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	removed, err := resolver.Mutation().RemoveVipEntry(context.Background(), "1", "2")
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_RemoveVipEntry_InvalidID(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	removed, err := resolver.Mutation().RemoveVipEntry(accountContext(1), "1", "abc")
+
+	assert.Error(t, err)
+	assert.False(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_SetAccountStorage_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	storage, err := resolver.Mutation().SetAccountStorage(context.Background(), "1", 100, 5, nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, storage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_AddPremiumDays_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	account, err := resolver.Mutation().AddPremiumDays(context.Background(), "1", 30, "Purchase")
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, account)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_SetAccountType_RecordsActor(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Staff: true, Name: "alice"})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT type FROM accounts WHERE id = \\? FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow(1))
+	mock.ExpectExec("UPDATE accounts SET type").
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO api_account_audit").
+		WithArgs(1, models.AccountAuditAccountType, int64(1), int64(4), "alice", "New gamemaster").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "secret", "type", "premium_ends_at", "email", "creation"}).
+			AddRow(1, "acc", "", nil, 4, 0, "a@example.com", 0))
+
+	account, err := resolver.Mutation().SetAccountType(ctx, "1", 4, "New gamemaster")
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, account.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountResolver_Premium(t *testing.T) {
+	resolver, _, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	endsAt := int(time.Now().Add(36 * time.Hour).Unix())
+
+	premium, err := resolver.Account().Premium(context.Background(), &models.Account{PremiumEndsAt: endsAt})
+
+	require.NoError(t, err)
+	assert.True(t, premium.Active)
+	assert.Equal(t, 2, premium.DaysLeft)
+}
+
+func TestAccountResolver_AuditLog_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	entries, err := resolver.Account().AuditLog(context.Background(), &models.Account{ID: 1})
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_CreateAccount_SendsVerification(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	sender := mail.NewMemorySender()
+	resolver.AccountTokenRepository.SetMailer(sender, models.DefaultAccountMail())
+
+	mock.ExpectExec("INSERT INTO accounts").
+		WithArgs("newuser", models.HashPassword("password123"), "newuser@example.com").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "secret", "type", "premium_ends_at", "email", "creation"}).
+			AddRow(1, "newuser", "", nil, 1, 0, "newuser@example.com", 0))
+	mock.ExpectExec("INSERT INTO api_account_tokens").
+		WithArgs(sqlmock.AnyArg(), 1, "EMAIL_VERIFY", "newuser@example.com", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err := resolver.Mutation().CreateAccount(context.Background(), models.CreateAccountInput{
+		Name: "newuser", Password: "password123", Email: "newuser@example.com",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, sender.Messages(), 1)
+	assert.Equal(t, "newuser@example.com", sender.Messages()[0].To)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePassword_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	changed, err := resolver.Mutation().ChangePassword(context.Background(), "old-password", "new-password")
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.False(t, changed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_RequestPasswordReset_MailDisabled(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	sent, err := resolver.Mutation().RequestPasswordReset(context.Background(), "player@example.com")
+
+	assert.ErrorIs(t, err, models.ErrMailDisabled)
+	assert.False(t, sent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_GenerateRecoveryKey_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	key, err := resolver.Mutation().GenerateRecoveryKey(staffContext(), "password123")
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Empty(t, key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_RecoverAccount_RateLimited(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT a.id AS account_id, k.key_hash FROM accounts a").
+		WithArgs("player").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "key_hash"}).AddRow(1, "hash"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_account_recovery_attempts").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectRollback()
+
+	recovered, err := resolver.Mutation().RecoverAccount(context.Background(), "player", "ABCD-EFGH", "new-password", "new@example.com")
+
+	assert.ErrorIs(t, err, models.ErrTooManyAttempts)
+	assert.False(t, recovered)
+	assert.Equal(t, "TOO_MANY_ATTEMPTS", ErrorPresenter(context.Background(), err).Extensions["code"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResolver_SearchAccounts_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	accounts, err := resolver.Query().SearchAccounts(context.Background(), "support", nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, accounts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
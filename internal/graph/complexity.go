@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+)
+
+// Sizes assumed for lists without a page size argument when estimating an
+// operation's cost. They only need the right order of magnitude.
+const (
+	fewItems  = 10  // an account's characters, a guild's ranks, quest missions
+	someItems = 50  // deaths, storage values, VIP entries, guild members
+	manyItems = 200 // server-wide lists such as online players, guilds and houses
+)
+
+// samplesPerHour is the number of onlineHistory samples recorded per hour at
+// the default sampling interval.
+const samplesPerHour = 12
+
+// NewComplexity returns the cost of each list field: every field costs 1
+// plus its selections, and list fields multiply their selections by their
+// page size, or by an assumed size when they have none.
+func NewComplexity() ComplexityRoot {
+	var c ComplexityRoot
+
+	c.Query.Accounts = func(child int, limit *int) int { return listCost(child, pageSize(limit, 10)) }
+	c.Query.SearchAccounts = func(child int, query string, limit *int) int {
+		return listCost(child, pageSize(limit, 20))
+	}
+	c.Query.Players = func(child int, accountID string) int { return listCost(child, fewItems) }
+	c.Query.PlayersOnline = func(child int) int { return listCost(child, manyItems) }
+	c.Query.PlayersByStorage = func(child int, key int, value int, op *models.StorageComparison, limit *int) int {
+		return listCost(child, min(pageSize(limit, 100), models.MaxPlayersByStorage))
+	}
+	c.Query.SearchPlayers = func(child int, query string, vocation *int, levelRange *models.LevelRange, online *bool, first *int, after *string) int {
+		return listCost(child, pageSize(first, 20))
+	}
+	c.Query.Towns = func(child int) int { return listCost(child, fewItems) }
+	c.Query.Guilds = func(child int) int { return listCost(child, manyItems) }
+	c.Query.GuildWars = func(child int, guildID *string) int { return listCost(child, someItems) }
+	c.Query.Houses = func(child int, townID *string) int { return listCost(child, manyItems) }
+	c.Query.MarketOffers = func(child int, itemType *int) int { return listCost(child, manyItems) }
+	c.Query.MarketHistory = func(child int, playerID string) int { return listCost(child, someItems) }
+	c.Query.Quests = func(child int) int { return listCost(child, someItems) }
+	c.Query.Outfits = func(child int, sex *int) int { return listCost(child, someItems) }
+	c.Query.Mounts = func(child int) int { return listCost(child, someItems) }
+	c.Query.IPBans = func(child int) int { return listCost(child, someItems) }
+	c.Query.OnlineHistory = func(child int, hours *int) int {
+		return listCost(child, pageSize(hours, 24)*samplesPerHour)
+	}
+
+	c.Account.Players = func(child int) int { return listCost(child, fewItems) }
+	c.Account.Bans = func(child int) int { return listCost(child, fewItems) }
+	c.Account.Storage = func(child int) int { return listCost(child, someItems) }
+	c.Account.VipList = func(child int) int { return listCost(child, someItems) }
+	c.Account.AuditLog = func(child int) int { return listCost(child, someItems) }
+
+	c.Player.Deaths = func(child int) int { return listCost(child, someItems) }
+	c.Player.Storage = func(child int, keys []int, rangeArg *models.StorageRange) int {
+		if keys != nil {
+			return listCost(child, len(keys))
+		}
+		return listCost(child, manyItems)
+	}
+	c.Player.Quests = func(child int) int { return listCost(child, someItems) }
+	c.Player.Outfits = func(child int) int { return listCost(child, someItems) }
+	c.Player.Mounts = func(child int) int { return listCost(child, someItems) }
+	c.Player.NameHistory = func(child int) int { return listCost(child, fewItems) }
+
+	c.CharacterProfile.Deaths = func(child int, limit *int) int { return listCost(child, pageSize(limit, 10)) }
+	c.CharacterProfile.OtherCharacters = func(child int) int { return listCost(child, fewItems) }
+
+	c.Quest.Missions = func(child int) int { return listCost(child, fewItems) }
+	c.PlayerQuest.Missions = func(child int) int { return listCost(child, fewItems) }
+
+	c.Guild.Ranks = func(child int) int { return listCost(child, fewItems) }
+	c.Guild.Members = func(child int) int { return listCost(child, someItems) }
+	c.GuildWar.Kills = func(child int) int { return listCost(child, manyItems) }
+
+	return c
+}
+
+// pageSize returns a page size argument, or fallback when it is omitted.
+// Sizes below 1 still cost one item, since the field is still resolved.
+func pageSize(arg *int, fallback int) int {
+	if arg == nil {
+		return fallback
+	}
+	return max(*arg, 1)
+}
+
+// listCost saturates instead of overflowing, so huge page sizes can't wrap
+// around to a cost under the limit.
+func listCost(child, size int) int {
+	if child > 0 && size > (math.MaxInt-1)/child {
+		return math.MaxInt
+	}
+	return 1 + size*child
+}
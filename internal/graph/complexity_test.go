@@ -0,0 +1,28 @@
+package graph
+
+import (
+	"math"
+	"testing"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewComplexity(t *testing.T) {
+	c := NewComplexity()
+	limit := 5
+
+	assert.Equal(t, 1+10*3, c.Query.Accounts(3, nil), "the default page size")
+	assert.Equal(t, 1+5*3, c.Query.Accounts(3, &limit))
+	assert.Equal(t, 1+1*3, c.Query.Accounts(3, new(int)), "an empty page still resolves")
+	assert.Equal(t, 1+24*samplesPerHour*2, c.Query.OnlineHistory(2, nil))
+	assert.Equal(t, 1+2*4, c.Player.Storage(4, []int{1, 2}, nil), "requested keys bound storage")
+	assert.Equal(t, 1+manyItems*4, c.Player.Storage(4, nil, nil))
+	huge := 1 << 30
+	assert.Equal(t, 1+models.MaxPlayersByStorage*2, c.Query.PlayersByStorage(2, 1, 0, nil, &huge), "limits above the cap cost the cap")
+}
+
+func TestListCost_Saturates(t *testing.T) {
+	assert.Equal(t, math.MaxInt, listCost(math.MaxInt/2, 1<<31-1))
+	assert.Equal(t, 1, listCost(0, 1<<31-1))
+}
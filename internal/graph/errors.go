@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// errorCodes maps repository errors to the code reported in the GraphQL
+// error extensions.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{auth.ErrForbidden, "FORBIDDEN"},
+	{auth.ErrUnauthenticated, "UNAUTHENTICATED"},
+	{auth.ErrNotOwner, "FORBIDDEN"},
+	{ErrModeratorNotSet, "MODERATOR_NOT_SET"},
+	{models.ErrNotNamelocked, "NOT_NAMELOCKED"},
+	{models.ErrCharacterLimit, "CHARACTER_LIMIT"},
+	{models.ErrGuildLeader, "GUILD_LEADER"},
+	{models.ErrHouseOwner, "HOUSE_OWNER"},
+	{models.ErrDeletionNotPending, "DELETION_NOT_PENDING"},
+	{models.ErrDeletionAlreadySet, "DELETION_ALREADY_SCHEDULED"},
+	{models.ErrOutfitStorageFull, "OUTFIT_STORAGE_FULL"},
+	{models.ErrInvalidMount, "INVALID_MOUNT"},
+	{models.ErrVipListFull, "VIP_LIST_FULL"},
+	{models.ErrVipEntryExists, "VIP_ENTRY_EXISTS"},
+	{models.ErrVipEntryNotFound, "VIP_ENTRY_NOT_FOUND"},
+	{models.ErrInvalidVipEntry, "INVALID_VIP_ENTRY"},
+	{models.ErrInvalidPremiumDays, "INVALID_PREMIUM_DAYS"},
+	{models.ErrInvalidAccountType, "INVALID_ACCOUNT_TYPE"},
+	{models.ErrAuditReasonRequired, "REASON_REQUIRED"},
+	{models.ErrInvalidLogin, "INVALID_LOGIN"},
+	{models.ErrWrongPassword, "WRONG_PASSWORD"},
+	{models.ErrInvalidPassword, "INVALID_PASSWORD"},
+	{models.ErrInvalidToken, "INVALID_TOKEN"},
+	{models.ErrMailDisabled, "MAIL_DISABLED"},
+	{models.ErrInvalidRecoveryKey, "INVALID_RECOVERY_KEY"},
+	{models.ErrTooManyAttempts, "TOO_MANY_ATTEMPTS"},
+	{models.ErrInvalidEmail, "INVALID_EMAIL"},
+	{models.ErrInvalidSearch, "INVALID_SEARCH"},
+	{models.ErrInvalidCursor, "INVALID_CURSOR"},
+	{models.ErrInvalidHistoryRange, "INVALID_HISTORY_RANGE"},
+}
+
+// ErrorPresenter adds a machine-readable code to errors returned by the
+// repositories, so clients can tell validation failures apart.
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var nameErr *models.NameError
+	if errors.As(err, &nameErr) {
+		gqlErr.Message = nameErr.Error()
+		gqlErr.Extensions = map[string]interface{}{
+			"code": "INVALID_NAME",
+			"rule": string(nameErr.Rule),
+		}
+	}
+
+	var onlineErr *models.PlayerOnlineError
+	if errors.As(err, &onlineErr) {
+		gqlErr.Extensions = map[string]interface{}{
+			"code":     "PLAYER_ONLINE",
+			"playerId": strconv.Itoa(onlineErr.PlayerID),
+		}
+	}
+
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			gqlErr.Extensions = map[string]interface{}{"code": c.code}
+			break
+		}
+	}
+
+	// Errors without a code are unexpected, usually a failed query.
+	if _, ok := gqlErr.Extensions["code"]; !ok {
+		slog.ErrorContext(ctx, "GraphQL request failed", "path", gqlErr.Path.String(), "error", err)
+	}
+
+	return gqlErr
+}
+
+// RecoverFunc logs a panic in a resolver with its stack and reports an
+// internal error to the client.
+func RecoverFunc(ctx context.Context, err interface{}) error {
+	slog.ErrorContext(ctx, "Resolver panicked", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+	return gqlerror.Errorf("internal system error")
+}
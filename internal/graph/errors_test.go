@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPresenter_NameError(t *testing.T) {
+	err := fmt.Errorf("create player: %w", models.ErrNameTaken)
+
+	gqlErr := ErrorPresenter(context.Background(), err)
+
+	assert.Equal(t, "invalid name: name is already in use", gqlErr.Message)
+	assert.Equal(t, "INVALID_NAME", gqlErr.Extensions["code"])
+	assert.Equal(t, "TAKEN", gqlErr.Extensions["rule"])
+}
+
+func TestErrorPresenter_OtherError(t *testing.T) {
+	gqlErr := ErrorPresenter(context.Background(), errors.New("boom"))
+
+	assert.Equal(t, "boom", gqlErr.Message)
+	assert.Nil(t, gqlErr.Extensions)
+}
+
+func TestErrorPresenter_CharacterLimit(t *testing.T) {
+	gqlErr := ErrorPresenter(context.Background(), models.ErrCharacterLimit)
+
+	assert.Equal(t, "CHARACTER_LIMIT", gqlErr.Extensions["code"])
+}
+
+func TestErrorPresenter_PlayerOnline(t *testing.T) {
+	err := fmt.Errorf("place bid: %w", &models.PlayerOnlineError{PlayerID: 7})
+
+	gqlErr := ErrorPresenter(context.Background(), err)
+
+	assert.Equal(t, "PLAYER_ONLINE", gqlErr.Extensions["code"])
+	assert.Equal(t, "7", gqlErr.Extensions["playerId"])
+}
+
+func TestErrorPresenter_Forbidden(t *testing.T) {
+	gqlErr := ErrorPresenter(context.Background(), auth.ErrForbidden)
+
+	assert.Equal(t, "FORBIDDEN", gqlErr.Extensions["code"])
+}
+
+func TestErrorPresenter_VipListFull(t *testing.T) {
+	gqlErr := ErrorPresenter(context.Background(), models.ErrVipListFull)
+
+	assert.Equal(t, "VIP_LIST_FULL", gqlErr.Extensions["code"])
+}
+
+func TestErrorPresenter_NotNamelocked(t *testing.T) {
+	err := fmt.Errorf("resolve namelock: %w", models.ErrNotNamelocked)
+
+	gqlErr := ErrorPresenter(context.Background(), err)
+
+	assert.Equal(t, "NOT_NAMELOCKED", gqlErr.Extensions["code"])
+}
+
+func TestErrorPresenter_LogsUnexpectedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(previous)
+
+	ErrorPresenter(context.Background(), models.ErrVipListFull)
+	assert.Empty(t, buf.String(), "errors with a code are the client's to handle")
+
+	ErrorPresenter(context.Background(), fmt.Errorf("failed to get account: %w", errors.New("connection refused")))
+	assert.Contains(t, buf.String(), "failed to get account: connection refused")
+}
@@ -0,0 +1,109 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResolver_IPBan_NotFound(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans WHERE ip = ?").
+		WithArgs(uint32(0x0100007f)).
+		WillReturnError(sql.ErrNoRows)
+
+	ban, err := resolver.Query().IPBan(staffContext(), "127.0.0.1")
+
+	require.NoError(t, err)
+	assert.Nil(t, ban)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResolver_IPBans_RequiresStaff(t *testing.T) {
+	resolver, _, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	bans, err := resolver.Query().IPBans(context.Background())
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, bans)
+}
+
+func TestMutationResolver_BanIP_RecordsModerator(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+	resolver.ModeratorPlayerID = 9
+
+	mock.ExpectExec("INSERT INTO ip_bans").
+		WithArgs(uint32(0x0100007f), "botting", int64(0), 9).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans WHERE ip = ?").
+		WithArgs(uint32(0x0100007f)).
+		WillReturnRows(sqlmock.NewRows([]string{"ip", "reason", "banned_at", "expires_at", "banned_by"}).
+			AddRow(uint32(0x0100007f), "botting", 1700000000, 0, 9))
+
+	ban, err := resolver.Mutation().BanIP(staffContext(), models.BanIpInput{IP: "127.0.0.1", Reason: "botting", BannedBy: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, 9, ban.BannedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_NamelockPlayer_ModeratorNotSet(t *testing.T) {
+	resolver, _, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	namelock, err := resolver.Mutation().NamelockPlayer(staffContext(), "1", "offensive name")
+
+	assert.ErrorIs(t, err, ErrModeratorNotSet)
+	assert.Nil(t, namelock)
+}
+
+func TestMutationResolver_UnbanIP(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM ip_bans WHERE ip = ?").
+		WithArgs(uint32(0x0100007f)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	unbanned, err := resolver.Mutation().UnbanIP(staffContext(), "127.0.0.1")
+
+	require.NoError(t, err)
+	assert.False(t, unbanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerResolver_Namelock_None(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT player_id, reason, namelocked_at, namelocked_by FROM player_namelocks WHERE player_id = ?").
+		WithArgs(1).
+		WillReturnError(sql.ErrNoRows)
+
+	namelock, err := resolver.Player().Namelock(context.Background(), &models.Player{ID: 1})
+
+	require.NoError(t, err)
+	assert.Nil(t, namelock)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ResolveNamelock_InvalidID(t *testing.T) {
+	resolver, _, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	player, err := resolver.Mutation().ResolveNamelock(staffContext(), "abc", "Sir Lancelot", nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, player)
+	assert.Contains(t, err.Error(), "invalid player id")
+}
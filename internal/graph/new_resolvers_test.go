@@ -192,9 +192,14 @@ func TestMutationResolver_CreateGuild(t *testing.T) {
 		OwnerID: 1,
 	}
 
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectExec("INSERT INTO guilds").
 		WithArgs(input.Name, input.OwnerID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{"id", "name", "ownerid", "creationdata", "motd"}).
 		AddRow(1, input.Name, input.OwnerID, 1234567890, "")
@@ -203,7 +208,7 @@ func TestMutationResolver_CreateGuild(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(rows)
 
-	guild, err := resolver.Mutation().CreateGuild(context.Background(), input)
+	guild, err := resolver.Mutation().CreateGuild(context.Background(), input, nil)
 
 	require.NoError(t, err)
 	assert.Equal(t, "New Guild", guild.Name)
@@ -252,9 +257,14 @@ func TestMutationResolver_CreateMarketOffer(t *testing.T) {
 		Anonymous: false,
 	}
 
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 	mock.ExpectExec("INSERT INTO market_offers").
 		WithArgs(input.PlayerID, input.Sale, input.ItemType, input.Amount, input.Anonymous, input.Price).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{
 		"id", "player_id", "sale", "itemtype", "amount", "created", "anonymous", "price",
@@ -264,7 +274,7 @@ func TestMutationResolver_CreateMarketOffer(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(rows)
 
-	offer, err := resolver.Mutation().CreateMarketOffer(context.Background(), input)
+	offer, err := resolver.Mutation().CreateMarketOffer(context.Background(), input, nil)
 
 	require.NoError(t, err)
 	assert.Equal(t, 2160, offer.ItemType)
@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutationResolver_BidHouse_PlayerOnline(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	house, err := resolver.Mutation().BidHouse(context.Background(), "1", "2", 5000, nil)
+
+	assert.ErrorIs(t, err, models.ErrPlayerOnline)
+	assert.Nil(t, house)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_BidHouse_ForceRequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	force := true
+	house, err := resolver.Mutation().BidHouse(context.Background(), "1", "2", 5000, &force)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, house)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_BidHouse_StaffForce(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE houses SET bid").
+		WithArgs(5000, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM houses WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bid", "highest_bidder"}).AddRow(1, 5000, 2))
+
+	force := true
+	house, err := resolver.Mutation().BidHouse(staffContext(), "1", "2", 5000, &force)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, house.HighestBidder)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_AcceptGuildInvite_PlayerOnline(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	ok, err := resolver.Mutation().AcceptGuildInvite(context.Background(), "1", "2", nil)
+
+	assert.ErrorIs(t, err, models.ErrPlayerOnline)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ScheduleCharacterDeletion_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	player, err := resolver.Mutation().ScheduleCharacterDeletion(context.Background(), "1", nil)
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_CancelCharacterDeletion_OtherAccount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "account_id"}).AddRow(1, "Player", 1))
+
+	player, err := resolver.Mutation().CancelCharacterDeletion(accountContext(2), "1", nil)
+
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerName_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	player, err := resolver.Mutation().ChangePlayerName(context.Background(), "1", "New Name", nil)
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerName_OtherAccount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "account_id"}).AddRow(1, "Player", 1))
+
+	player, err := resolver.Mutation().ChangePlayerName(accountContext(2), "1", "New Name", nil)
+
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerSex_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	player, err := resolver.Mutation().ChangePlayerSex(context.Background(), "1", nil)
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerSex_OtherAccount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "account_id"}).AddRow(1, "Player", 1))
+
+	player, err := resolver.Mutation().ChangePlayerSex(accountContext(2), "1", nil)
+
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerTown_RequiresSession(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	player, err := resolver.Mutation().ChangePlayerTown(context.Background(), "1", "2", nil)
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_ChangePlayerTown_OtherAccount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "account_id"}).AddRow(1, "Player", 1))
+
+	player, err := resolver.Mutation().ChangePlayerTown(accountContext(2), "1", "2", nil)
+
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
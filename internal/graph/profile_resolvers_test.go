@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResolver_CharacterProfile_NotFound(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM players WHERE name = \\?").
+		WithArgs("Nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	profile, err := resolver.Query().CharacterProfile(context.Background(), "Nobody")
+
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCharacterProfileResolver_OtherCharacters_Hidden(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT hide_characters FROM api_account_preferences").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hide_characters"}).AddRow(true))
+
+	profile := &models.CharacterProfile{Player: &models.Player{ID: 2, AccountID: 1}}
+	players, err := resolver.CharacterProfile().OtherCharacters(context.Background(), profile)
+
+	require.NoError(t, err)
+	assert.Empty(t, players)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCharacterProfileResolver_House_None(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM houses WHERE owner = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	house, err := resolver.CharacterProfile().House(context.Background(), &models.CharacterProfile{Player: &models.Player{ID: 2}})
+
+	require.NoError(t, err)
+	assert.Nil(t, house)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,37 +1,150 @@
 package graph
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/status"
 )
 
 // Resolver is the root GraphQL resolver
 type Resolver struct {
-	DB                       *database.DB
-	AccountRepository        *models.AccountRepository
-	AccountBanRepository     *models.AccountBanRepository
-	AccountStorageRepository *models.AccountStorageRepository
-	PlayerRepository         *models.PlayerRepository
-	PlayerDeathRepository    *models.PlayerDeathRepository
-	PlayerStorageRepository  *models.PlayerStorageRepository
-	TownRepository           *models.TownRepository
-	GuildRepository          *models.GuildRepository
-	HouseRepository          *models.HouseRepository
-	MarketRepository         *models.MarketRepository
+	DB                        *database.DB
+	AccountRepository         *models.AccountRepository
+	AccountBanRepository      *models.AccountBanRepository
+	AccountStorageRepository  *models.AccountStorageRepository
+	AccountSessionRepository  *models.AccountSessionRepository
+	AccountTokenRepository    *models.AccountTokenRepository
+	AccountRecoveryRepository *models.AccountRecoveryRepository
+	PlayerRepository          *models.PlayerRepository
+	PlayerDeathRepository     *models.PlayerDeathRepository
+	PlayerStorageRepository   *models.PlayerStorageRepository
+	TownRepository            *models.TownRepository
+	GuildRepository           *models.GuildRepository
+	HouseRepository           *models.HouseRepository
+	MarketRepository          *models.MarketRepository
+	IpBanRepository           *models.IpBanRepository
+	PlayerNamelockRepository  *models.PlayerNamelockRepository
+	ServerConfigRepository    *models.ServerConfigRepository
+	OnlineHistoryRepository   *models.OnlineHistoryRepository
+	NameValidator             *models.NameValidator
+	QuestCatalog              *models.QuestCatalog
+	OutfitCatalog             *models.OutfitCatalog
+	MountCatalog              *models.MountCatalog
+	StatusMonitor             *status.Monitor
+
+	// How long a character scheduled for deletion can still be restored
+	DeletionGracePeriod time.Duration
+	// Player recorded as the author of IP bans and namelocks made by staff
+	ModeratorPlayerID int
 }
 
+// ErrModeratorNotSet is returned by moderation mutations when no moderator
+// player is configured to record them under.
+var ErrModeratorNotSet = errors.New("no moderator player is configured")
+
 func NewResolver(db *database.DB) *Resolver {
 	return &Resolver{
-		DB:                       db,
-		AccountRepository:        models.NewAccountRepository(db),
-		AccountBanRepository:     models.NewAccountBanRepository(db),
-		AccountStorageRepository: models.NewAccountStorageRepository(db),
-		PlayerRepository:         models.NewPlayerRepository(db),
-		PlayerDeathRepository:    models.NewPlayerDeathRepository(db),
-		PlayerStorageRepository:  models.NewPlayerStorageRepository(db),
-		TownRepository:           models.NewTownRepository(db),
-		GuildRepository:          models.NewGuildRepository(db),
-		HouseRepository:          models.NewHouseRepository(db),
-		MarketRepository:         models.NewMarketRepository(db),
+		DB:                        db,
+		AccountRepository:         models.NewAccountRepository(db),
+		AccountBanRepository:      models.NewAccountBanRepository(db),
+		AccountStorageRepository:  models.NewAccountStorageRepository(db),
+		AccountSessionRepository:  models.NewAccountSessionRepository(db),
+		AccountTokenRepository:    models.NewAccountTokenRepository(db),
+		AccountRecoveryRepository: models.NewAccountRecoveryRepository(db),
+		PlayerRepository:          models.NewPlayerRepository(db),
+		PlayerDeathRepository:     models.NewPlayerDeathRepository(db),
+		PlayerStorageRepository:   models.NewPlayerStorageRepository(db),
+		TownRepository:            models.NewTownRepository(db),
+		GuildRepository:           models.NewGuildRepository(db),
+		HouseRepository:           models.NewHouseRepository(db),
+		MarketRepository:          models.NewMarketRepository(db),
+		IpBanRepository:           models.NewIpBanRepository(db),
+		PlayerNamelockRepository:  models.NewPlayerNamelockRepository(db),
+		ServerConfigRepository:    models.NewServerConfigRepository(db),
+		OnlineHistoryRepository:   models.NewOnlineHistoryRepository(db),
+		NameValidator:             models.NewNameValidator(db, models.DefaultNameRules()),
+		QuestCatalog:              &models.QuestCatalog{},
+		OutfitCatalog:             &models.OutfitCatalog{},
+		MountCatalog:              &models.MountCatalog{},
+		StatusMonitor:             status.NewMonitor(status.NewClient("127.0.0.1:7171"), 30*time.Second),
+		DeletionGracePeriod:       30 * 24 * time.Hour,
+	}
+}
+
+// onlineGuardContext lets a staff request write to a player that is online
+// when force is set. Anyone else asking to force gets auth.ErrForbidden.
+func onlineGuardContext(ctx context.Context, force *bool) (context.Context, error) {
+	if force == nil || !*force {
+		return ctx, nil
+	}
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return models.WithOnlineOverride(ctx), nil
+}
+
+// staffWriteContext is onlineGuardContext for mutations only staff may call.
+func staffWriteContext(ctx context.Context, force *bool) (context.Context, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return onlineGuardContext(ctx, force)
+}
+
+// requirePlayerOwner lets staff and sessions of the player's account through.
+func (r *Resolver) requirePlayerOwner(ctx context.Context, playerID int) error {
+	if auth.IsStaff(ctx) {
+		return nil
+	}
+	if _, err := auth.RequireAccount(ctx); err != nil {
+		return err
+	}
+	player, err := r.PlayerRepository.GetByID(ctx, playerID)
+	if err != nil {
+		return err
+	}
+	return auth.RequireOwner(ctx, player.AccountID)
+}
+
+// accountPlayers lists an account's characters. Only the owner and staff see
+// characters the owner chose to hide.
+func (r *Resolver) accountPlayers(ctx context.Context, accountID int) ([]*models.Player, error) {
+	if auth.RequireOwner(ctx, accountID) != nil {
+		hidden, err := r.AccountRepository.HidesCharacters(ctx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if hidden {
+			return []*models.Player{}, nil
+		}
+	}
+	return r.PlayerRepository.GetByAccountID(ctx, accountID)
+}
+
+// auditInfo records the staff member making a request as the actor of an
+// account change.
+func auditInfo(ctx context.Context, reason string) models.AuditInfo {
+	actor := "staff"
+	if principal := auth.FromContext(ctx); principal != nil && principal.Name != "" {
+		actor = principal.Name
+	}
+	return models.AuditInfo{Actor: actor, Reason: reason}
+}
+
+// moderator returns the player to record as the author of a moderation
+// action. Only staff may moderate; the staff member is logged with the
+// request.
+func (r *Resolver) moderator(ctx context.Context) (int, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return 0, err
+	}
+	if r.ModeratorPlayerID <= 0 {
+		return 0, ErrModeratorNotSet
 	}
+	return r.ModeratorPlayerID, nil
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
 	"github.com/jmoiron/sqlx"
@@ -42,7 +43,7 @@ func TestQueryResolver_Account(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(rows)
 
-	account, err := resolver.Query().Account(context.Background(), "1")
+	account, err := resolver.Query().Account(accountContext(1), "1")
 
 	require.NoError(t, err)
 	assert.Equal(t, 1, account.ID)
@@ -61,6 +62,21 @@ func TestQueryResolver_Account_InvalidID(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid account id")
 }
 
+func TestQueryResolver_Account_RequiresOwner(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	account, err := resolver.Query().Account(context.Background(), "1")
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, account)
+
+	account, err = resolver.Query().Account(accountContext(2), "1")
+	assert.ErrorIs(t, err, auth.ErrNotOwner)
+	assert.Nil(t, account)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestQueryResolver_Accounts(t *testing.T) {
 	resolver, mock, cleanup := setupTestResolver(t)
 	defer cleanup()
@@ -74,13 +90,24 @@ func TestQueryResolver_Accounts(t *testing.T) {
 		WithArgs(limit).
 		WillReturnRows(rows)
 
-	accounts, err := resolver.Query().Accounts(context.Background(), &limit)
+	accounts, err := resolver.Query().Accounts(staffContext(), &limit)
 
 	require.NoError(t, err)
 	assert.Len(t, accounts, 2)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestQueryResolver_Accounts_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	accounts, err := resolver.Query().Accounts(accountContext(1), nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, accounts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestQueryResolver_Player(t *testing.T) {
 	resolver, mock, cleanup := setupTestResolver(t)
 	defer cleanup()
@@ -121,13 +148,28 @@ func TestQueryResolver_Players(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(rows)
 
-	players, err := resolver.Query().Players(context.Background(), "1")
+	players, err := resolver.Query().Players(accountContext(1), "1")
 
 	require.NoError(t, err)
 	assert.Len(t, players, 2)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestQueryResolver_Players_Hidden(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT hide_characters FROM api_account_preferences WHERE account_id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hide_characters"}).AddRow(true))
+
+	players, err := resolver.Query().Players(accountContext(2), "1")
+
+	require.NoError(t, err)
+	assert.Empty(t, players)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // Mutation Resolver Tests
 
 func TestMutationResolver_CreateAccount(t *testing.T) {
@@ -141,7 +183,7 @@ func TestMutationResolver_CreateAccount(t *testing.T) {
 	}
 
 	mock.ExpectExec("INSERT INTO accounts").
-		WithArgs(input.Name, input.Password, input.Email).
+		WithArgs(input.Name, models.HashPassword(input.Password), input.Email).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	rows := sqlmock.NewRows([]string{"id", "name", "password", "secret", "type", "premium_ends_at", "email", "creation"}).
@@ -164,15 +206,31 @@ func TestMutationResolver_CreatePlayer(t *testing.T) {
 	defer cleanup()
 
 	input := models.CreatePlayerInput{
-		Name:      "NewPlayer",
+		Name:      "New Player",
 		AccountID: 1,
 		Sex:       1,
 		Vocation:  4,
 	}
 
+	mock.ExpectQuery("SELECT p.id, nl.player_id IS NOT NULL AS namelocked FROM players p").
+		WithArgs(input.Name, 0).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_player_name_history").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM accounts WHERE id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(input.AccountID))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players WHERE account_id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT id, name, posx, posy, posz FROM towns WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "posx", "posy", "posz"}).AddRow(1, "Thais", 100, 200, 7))
 	mock.ExpectExec("INSERT INTO players").
-		WithArgs(input.Name, input.AccountID, input.Sex, input.Vocation).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{
 		"id", "name", "group_id", "account_id", "level", "vocation", "health", "healthmax",
@@ -193,6 +251,48 @@ func TestMutationResolver_CreatePlayer(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestMutationResolver_CreatePlayer_InvalidName(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	input := models.CreatePlayerInput{
+		Name:      "GM Bob",
+		AccountID: 1,
+		Sex:       1,
+		Vocation:  4,
+	}
+
+	player, err := resolver.Mutation().CreatePlayer(context.Background(), input)
+
+	assert.Nil(t, player)
+	var nameErr *models.NameError
+	require.ErrorAs(t, err, &nameErr)
+	assert.Equal(t, models.NameRuleReservedPrefix, nameErr.Rule)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_CreatePlayer_NameTaken(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	input := models.CreatePlayerInput{
+		Name:      "Existing Player",
+		AccountID: 1,
+		Sex:       1,
+		Vocation:  4,
+	}
+
+	mock.ExpectQuery("SELECT p.id, nl.player_id IS NOT NULL AS namelocked FROM players p").
+		WithArgs(input.Name, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "namelocked"}).AddRow(7, false))
+
+	player, err := resolver.Mutation().CreatePlayer(context.Background(), input)
+
+	assert.Nil(t, player)
+	assert.ErrorIs(t, err, models.ErrNameTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // Field Resolver Tests
 
 func TestAccountResolver_Players(t *testing.T) {
@@ -215,7 +315,7 @@ func TestAccountResolver_Players(t *testing.T) {
 		WithArgs(account.ID).
 		WillReturnRows(rows)
 
-	players, err := resolver.Account().Players(context.Background(), account)
+	players, err := resolver.Account().Players(accountContext(1), account)
 
 	require.NoError(t, err)
 	assert.Len(t, players, 1)
@@ -223,6 +323,21 @@ func TestAccountResolver_Players(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAccountResolver_Players_Hidden(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT hide_characters FROM api_account_preferences WHERE account_id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hide_characters"}).AddRow(true))
+
+	players, err := resolver.Account().Players(context.Background(), &models.Account{ID: 1})
+
+	require.NoError(t, err)
+	assert.Empty(t, players)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPlayerResolver_Account(t *testing.T) {
 	resolver, mock, cleanup := setupTestResolver(t)
 	defer cleanup()
@@ -240,7 +355,7 @@ func TestPlayerResolver_Account(t *testing.T) {
 		WithArgs(player.AccountID).
 		WillReturnRows(rows)
 
-	account, err := resolver.Player().Account(context.Background(), player)
+	account, err := resolver.Player().Account(accountContext(1), player)
 
 	require.NoError(t, err)
 	assert.Equal(t, 1, account.ID)
@@ -262,9 +377,20 @@ func TestPlayerResolver_Account_NotFound(t *testing.T) {
 		WithArgs(player.AccountID).
 		WillReturnError(sql.ErrNoRows)
 
-	account, err := resolver.Player().Account(context.Background(), player)
+	account, err := resolver.Player().Account(staffContext(), player)
 
 	assert.Error(t, err)
 	assert.Nil(t, account)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestPlayerResolver_Account_Anonymous(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	account, err := resolver.Player().Account(context.Background(), &models.Player{ID: 1, AccountID: 1})
+
+	assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	assert.Nil(t, account)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -6,15 +6,41 @@ package graph
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"strconv"
+	"time"
 
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/status"
 )
 
+// EmailVerified is the resolver for the emailVerified field.
+func (r *accountResolver) EmailVerified(ctx context.Context, obj *models.Account) (bool, error) {
+	return r.AccountTokenRepository.EmailVerified(ctx, obj)
+}
+
+// HasRecoveryKey is the resolver for the hasRecoveryKey field.
+func (r *accountResolver) HasRecoveryKey(ctx context.Context, obj *models.Account) (bool, error) {
+	return r.AccountRecoveryRepository.HasKey(ctx, obj.ID)
+}
+
+// HideCharacters is the resolver for the hideCharacters field.
+func (r *accountResolver) HideCharacters(ctx context.Context, obj *models.Account) (bool, error) {
+	return r.AccountRepository.HidesCharacters(ctx, obj.ID)
+}
+
+// Premium is the resolver for the premium field.
+func (r *accountResolver) Premium(ctx context.Context, obj *models.Account) (*models.AccountPremium, error) {
+	return obj.Premium(time.Now()), nil
+}
+
 // Players is the resolver for the players field.
 func (r *accountResolver) Players(ctx context.Context, obj *models.Account) ([]*models.Player, error) {
-	return r.PlayerRepository.GetByAccountID(ctx, obj.ID)
+	return r.accountPlayers(ctx, obj.ID)
 }
 
 // Bans is the resolver for the bans field.
@@ -32,6 +58,14 @@ func (r *accountResolver) VipList(ctx context.Context, obj *models.Account) ([]*
 	return r.AccountStorageRepository.GetVipList(ctx, obj.ID)
 }
 
+// AuditLog is the resolver for the auditLog field.
+func (r *accountResolver) AuditLog(ctx context.Context, obj *models.Account) ([]*models.AccountAuditEntry, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return r.AccountRepository.GetAuditLog(ctx, obj.ID)
+}
+
 // Account is the resolver for the account field.
 func (r *accountBanResolver) Account(ctx context.Context, obj *models.AccountBan) (*models.Account, error) {
 	return r.AccountRepository.GetByID(ctx, obj.AccountID)
@@ -42,6 +76,64 @@ func (r *accountBanResolver) BannedBy(ctx context.Context, obj *models.AccountBa
 	return r.PlayerRepository.GetByID(ctx, obj.BannedBy)
 }
 
+// Online is the resolver for the online field.
+func (r *characterProfileResolver) Online(ctx context.Context, obj *models.CharacterProfile) (bool, error) {
+	return r.PlayerRepository.IsOnline(ctx, obj.Player.ID)
+}
+
+// Premium is the resolver for the premium field.
+func (r *characterProfileResolver) Premium(ctx context.Context, obj *models.CharacterProfile) (*models.AccountPremium, error) {
+	account, err := r.AccountRepository.GetByID(ctx, obj.Player.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	return account.Premium(time.Now()), nil
+}
+
+// Guild is the resolver for the guild field.
+func (r *characterProfileResolver) Guild(ctx context.Context, obj *models.CharacterProfile) (*models.GuildMembership, error) {
+	membership, err := r.GuildRepository.GetMembershipByPlayerID(ctx, obj.Player.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return membership, err
+}
+
+// House is the resolver for the house field.
+func (r *characterProfileResolver) House(ctx context.Context, obj *models.CharacterProfile) (*models.House, error) {
+	house, err := r.HouseRepository.GetByOwner(ctx, obj.Player.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return house, err
+}
+
+// Deaths is the resolver for the deaths field.
+func (r *characterProfileResolver) Deaths(ctx context.Context, obj *models.CharacterProfile, limit *int) ([]*models.PlayerDeath, error) {
+	deathLimit := 10
+	if limit != nil {
+		deathLimit = min(max(*limit, 0), 100)
+	}
+	return r.PlayerDeathRepository.GetRecent(ctx, obj.Player.ID, deathLimit)
+}
+
+// CharactersHidden is the resolver for the charactersHidden field.
+func (r *characterProfileResolver) CharactersHidden(ctx context.Context, obj *models.CharacterProfile) (bool, error) {
+	return r.AccountRepository.HidesCharacters(ctx, obj.Player.AccountID)
+}
+
+// OtherCharacters is the resolver for the otherCharacters field.
+func (r *characterProfileResolver) OtherCharacters(ctx context.Context, obj *models.CharacterProfile) ([]*models.Player, error) {
+	hidden, err := r.AccountRepository.HidesCharacters(ctx, obj.Player.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if hidden {
+		return []*models.Player{}, nil
+	}
+	return r.PlayerRepository.GetOtherCharacters(ctx, obj.Player.AccountID, obj.Player.ID)
+}
+
 // Owner is the resolver for the owner field.
 func (r *guildResolver) Owner(ctx context.Context, obj *models.Guild) (*models.Player, error) {
 	return r.PlayerRepository.GetByID(ctx, obj.OwnerID)
@@ -102,6 +194,11 @@ func (r *houseResolver) Town(ctx context.Context, obj *models.House) (*models.To
 	return r.TownRepository.GetByID(ctx, obj.TownID)
 }
 
+// BannedBy is the resolver for the bannedBy field.
+func (r *ipBanResolver) BannedBy(ctx context.Context, obj *models.IpBan) (*models.Player, error) {
+	return r.PlayerRepository.GetByID(ctx, obj.BannedBy)
+}
+
 // Player is the resolver for the player field.
 func (r *marketHistoryResolver) Player(ctx context.Context, obj *models.MarketHistory) (*models.Player, error) {
 	return r.PlayerRepository.GetByID(ctx, obj.PlayerID)
@@ -114,7 +211,15 @@ func (r *marketOfferResolver) Player(ctx context.Context, obj *models.MarketOffe
 
 // CreateAccount is the resolver for the createAccount field.
 func (r *mutationResolver) CreateAccount(ctx context.Context, input models.CreateAccountInput) (*models.Account, error) {
-	return r.AccountRepository.Create(ctx, input)
+	account, err := r.AccountRepository.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	// The account exists either way; the owner can ask for a new email
+	if err := r.AccountTokenRepository.SendVerification(ctx, account); err != nil && !errors.Is(err, models.ErrMailDisabled) {
+		slog.ErrorContext(ctx, "Failed to send verification email", "account_id", account.ID, "error", err)
+	}
+	return account, nil
 }
 
 // BanAccount is the resolver for the banAccount field.
@@ -122,23 +227,429 @@ func (r *mutationResolver) BanAccount(ctx context.Context, input models.BanAccou
 	return r.AccountBanRepository.Create(ctx, input)
 }
 
+// Login is the resolver for the login field.
+func (r *mutationResolver) Login(ctx context.Context, name string, password string) (*models.AccountSession, error) {
+	return r.AccountSessionRepository.Login(ctx, name, password)
+}
+
+// Logout is the resolver for the logout field.
+func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
+	if _, err := auth.RequireAccount(ctx); err != nil {
+		return false, err
+	}
+	return r.AccountSessionRepository.Logout(ctx, auth.FromContext(ctx).SessionToken)
+}
+
+// ChangePassword is the resolver for the changePassword field.
+func (r *mutationResolver) ChangePassword(ctx context.Context, oldPassword string, newPassword string) (bool, error) {
+	accountID, err := auth.RequireAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+	sessionToken := auth.FromContext(ctx).SessionToken
+	if err := r.AccountRepository.ChangePassword(ctx, accountID, sessionToken, oldPassword, newPassword); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RequestPasswordReset is the resolver for the requestPasswordReset field.
+func (r *mutationResolver) RequestPasswordReset(ctx context.Context, email string) (bool, error) {
+	err := r.AccountTokenRepository.RequestPasswordReset(ctx, email)
+	if errors.Is(err, models.ErrMailDisabled) {
+		return false, err
+	}
+	// Other failures aren't reported, so the response doesn't reveal
+	// whether the address belongs to an account
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send password reset", "error", err)
+	}
+	return true, nil
+}
+
+// ResetPassword is the resolver for the resetPassword field.
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, newPassword string) (bool, error) {
+	if err := r.AccountTokenRepository.ResetPassword(ctx, token, newPassword); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// VerifyEmail is the resolver for the verifyEmail field.
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (bool, error) {
+	if err := r.AccountTokenRepository.VerifyEmail(ctx, token); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResendVerificationEmail is the resolver for the resendVerificationEmail field.
+func (r *mutationResolver) ResendVerificationEmail(ctx context.Context) (bool, error) {
+	accountID, err := auth.RequireAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+	account, err := r.AccountRepository.GetByID(ctx, accountID)
+	if err != nil {
+		return false, err
+	}
+	if err := r.AccountTokenRepository.SendVerification(ctx, account); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetHideCharacters is the resolver for the setHideCharacters field.
+func (r *mutationResolver) SetHideCharacters(ctx context.Context, hide bool) (bool, error) {
+	accountID, err := auth.RequireAccount(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := r.AccountRepository.SetHideCharacters(ctx, accountID, hide); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GenerateRecoveryKey is the resolver for the generateRecoveryKey field.
+func (r *mutationResolver) GenerateRecoveryKey(ctx context.Context, password string) (string, error) {
+	accountID, err := auth.RequireAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+	return r.AccountRecoveryRepository.GenerateKey(ctx, accountID, password)
+}
+
+// RecoverAccount is the resolver for the recoverAccount field.
+func (r *mutationResolver) RecoverAccount(ctx context.Context, name string, recoveryKey string, newPassword string, newEmail string) (bool, error) {
+	ip := auth.ClientIP(ctx)
+	err := r.AccountRecoveryRepository.Recover(ctx, models.RecoverAccountInput{
+		Name:        name,
+		RecoveryKey: recoveryKey,
+		NewPassword: newPassword,
+		NewEmail:    newEmail,
+		IP:          ip,
+	})
+	switch {
+	case err == nil:
+		slog.InfoContext(ctx, "Account recovered with its recovery key", "account", name, "ip", ip)
+	case errors.Is(err, models.ErrInvalidRecoveryKey):
+		slog.WarnContext(ctx, "Failed recovery attempt", "account", name, "ip", ip)
+	case errors.Is(err, models.ErrTooManyAttempts):
+		slog.WarnContext(ctx, "Recovery attempt rate limited", "account", name, "ip", ip)
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddVipEntry is the resolver for the addVipEntry field.
+func (r *mutationResolver) AddVipEntry(ctx context.Context, input models.VipEntryInput) (*models.VipEntry, error) {
+	if err := auth.RequireOwner(ctx, input.AccountID); err != nil {
+		return nil, err
+	}
+	return r.AccountStorageRepository.AddVipEntry(ctx, input)
+}
+
+// UpdateVipEntry is the resolver for the updateVipEntry field.
+func (r *mutationResolver) UpdateVipEntry(ctx context.Context, input models.VipEntryInput) (*models.VipEntry, error) {
+	if err := auth.RequireOwner(ctx, input.AccountID); err != nil {
+		return nil, err
+	}
+	return r.AccountStorageRepository.UpdateVipEntry(ctx, input)
+}
+
+// RemoveVipEntry is the resolver for the removeVipEntry field.
+func (r *mutationResolver) RemoveVipEntry(ctx context.Context, accountID string, playerID string) (bool, error) {
+	aID, err := strconv.Atoi(accountID)
+	if err != nil {
+		return false, fmt.Errorf("invalid account id: %w", err)
+	}
+	if err := auth.RequireOwner(ctx, aID); err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	return r.AccountStorageRepository.RemoveVipEntry(ctx, aID, pID)
+}
+
+// SetAccountStorage is the resolver for the setAccountStorage field.
+func (r *mutationResolver) SetAccountStorage(ctx context.Context, accountID string, key int, value int, force *bool) (*models.AccountStorage, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	aID, err := strconv.Atoi(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account id: %w", err)
+	}
+	return r.AccountStorageRepository.SetStorage(ctx, aID, key, value)
+}
+
+// DeleteAccountStorage is the resolver for the deleteAccountStorage field.
+func (r *mutationResolver) DeleteAccountStorage(ctx context.Context, accountID string, key int, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	aID, err := strconv.Atoi(accountID)
+	if err != nil {
+		return false, fmt.Errorf("invalid account id: %w", err)
+	}
+	return r.AccountStorageRepository.DeleteStorage(ctx, aID, key)
+}
+
+// AddPremiumDays is the resolver for the addPremiumDays field.
+func (r *mutationResolver) AddPremiumDays(ctx context.Context, accountID string, days int, reason string) (*models.Account, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account id: %w", err)
+	}
+	return r.AccountRepository.AddPremiumDays(ctx, id, days, auditInfo(ctx, reason))
+}
+
+// SetAccountType is the resolver for the setAccountType field.
+func (r *mutationResolver) SetAccountType(ctx context.Context, accountID string, typeArg int, reason string) (*models.Account, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid account id: %w", err)
+	}
+	return r.AccountRepository.SetType(ctx, id, typeArg, auditInfo(ctx, reason))
+}
+
 // CreatePlayer is the resolver for the createPlayer field.
 func (r *mutationResolver) CreatePlayer(ctx context.Context, input models.CreatePlayerInput) (*models.Player, error) {
+	if err := r.NameValidator.Validate(ctx, input.Name, 0); err != nil {
+		return nil, err
+	}
 	return r.PlayerRepository.Create(ctx, input)
 }
 
+// ScheduleCharacterDeletion is the resolver for the scheduleCharacterDeletion field.
+func (r *mutationResolver) ScheduleCharacterDeletion(ctx context.Context, playerID string, force *bool) (*models.Player, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.requirePlayerOwner(ctx, pID); err != nil {
+		return nil, err
+	}
+	return r.PlayerRepository.ScheduleDeletion(ctx, pID, r.DeletionGracePeriod)
+}
+
+// CancelCharacterDeletion is the resolver for the cancelCharacterDeletion field.
+func (r *mutationResolver) CancelCharacterDeletion(ctx context.Context, playerID string, force *bool) (*models.Player, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.requirePlayerOwner(ctx, pID); err != nil {
+		return nil, err
+	}
+	return r.PlayerRepository.CancelDeletion(ctx, pID)
+}
+
+// ChangePlayerName is the resolver for the changePlayerName field.
+func (r *mutationResolver) ChangePlayerName(ctx context.Context, playerID string, newName string, force *bool) (*models.Player, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.requirePlayerOwner(ctx, pID); err != nil {
+		return nil, err
+	}
+	if err := r.NameValidator.Validate(ctx, newName, pID); err != nil {
+		return nil, err
+	}
+	return r.PlayerRepository.ChangeName(ctx, pID, newName)
+}
+
+// ChangePlayerSex is the resolver for the changePlayerSex field.
+func (r *mutationResolver) ChangePlayerSex(ctx context.Context, playerID string, force *bool) (*models.Player, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.requirePlayerOwner(ctx, pID); err != nil {
+		return nil, err
+	}
+	return r.PlayerRepository.ChangeSex(ctx, pID)
+}
+
+// ChangePlayerTown is the resolver for the changePlayerTown field.
+func (r *mutationResolver) ChangePlayerTown(ctx context.Context, playerID string, townID string, force *bool) (*models.Player, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.requirePlayerOwner(ctx, pID); err != nil {
+		return nil, err
+	}
+	tID, err := strconv.Atoi(townID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid town id: %w", err)
+	}
+	return r.PlayerRepository.ChangeTown(ctx, pID, tID)
+}
+
+// SetPlayerStorage is the resolver for the setPlayerStorage field.
+func (r *mutationResolver) SetPlayerStorage(ctx context.Context, playerID string, key int, value int, force *bool) (*models.PlayerStorage, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	return r.PlayerStorageRepository.Set(ctx, pID, key, value)
+}
+
+// IncrementPlayerStorage is the resolver for the incrementPlayerStorage field.
+func (r *mutationResolver) IncrementPlayerStorage(ctx context.Context, playerID string, key int, amount *int, force *bool) (*models.PlayerStorage, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	by := 1
+	if amount != nil {
+		by = *amount
+	}
+	return r.PlayerStorageRepository.Increment(ctx, pID, key, by)
+}
+
+// DeletePlayerStorage is the resolver for the deletePlayerStorage field.
+func (r *mutationResolver) DeletePlayerStorage(ctx context.Context, playerID string, key int, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	return r.PlayerStorageRepository.Delete(ctx, pID, key)
+}
+
+// GrantOutfit is the resolver for the grantOutfit field.
+func (r *mutationResolver) GrantOutfit(ctx context.Context, playerID string, lookType int, addons *int, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	flags := 0
+	if addons != nil {
+		flags = *addons
+	}
+	err = r.PlayerStorageRepository.GrantOutfit(ctx, pID, lookType, flags)
+	return err == nil, err
+}
+
+// RevokeOutfit is the resolver for the revokeOutfit field.
+func (r *mutationResolver) RevokeOutfit(ctx context.Context, playerID string, lookType int, addons *int, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	return r.PlayerStorageRepository.RevokeOutfit(ctx, pID, lookType, addons)
+}
+
+// GrantMount is the resolver for the grantMount field.
+func (r *mutationResolver) GrantMount(ctx context.Context, playerID string, mountID string, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	mID, err := strconv.Atoi(mountID)
+	if err != nil {
+		return false, fmt.Errorf("invalid mount id: %w", err)
+	}
+	err = r.PlayerStorageRepository.GrantMount(ctx, pID, mID)
+	return err == nil, err
+}
+
+// RevokeMount is the resolver for the revokeMount field.
+func (r *mutationResolver) RevokeMount(ctx context.Context, playerID string, mountID string, force *bool) (bool, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return false, fmt.Errorf("invalid player id: %w", err)
+	}
+	mID, err := strconv.Atoi(mountID)
+	if err != nil {
+		return false, fmt.Errorf("invalid mount id: %w", err)
+	}
+	return r.PlayerStorageRepository.RevokeMount(ctx, pID, mID)
+}
+
 // CreateTown is the resolver for the createTown field.
 func (r *mutationResolver) CreateTown(ctx context.Context, input models.CreateTownInput) (*models.Town, error) {
 	return r.TownRepository.Create(ctx, input)
 }
 
 // CreateGuild is the resolver for the createGuild field.
-func (r *mutationResolver) CreateGuild(ctx context.Context, input models.CreateGuildInput) (*models.Guild, error) {
+func (r *mutationResolver) CreateGuild(ctx context.Context, input models.CreateGuildInput, force *bool) (*models.Guild, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
 	return r.GuildRepository.Create(ctx, input)
 }
 
 // InviteToGuild is the resolver for the inviteToGuild field.
-func (r *mutationResolver) InviteToGuild(ctx context.Context, guildID string, playerID string) (bool, error) {
+func (r *mutationResolver) InviteToGuild(ctx context.Context, guildID string, playerID string, force *bool) (bool, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
 	gID, err := strconv.Atoi(guildID)
 	if err != nil {
 		return false, fmt.Errorf("invalid guild id: %w", err)
@@ -152,7 +663,11 @@ func (r *mutationResolver) InviteToGuild(ctx context.Context, guildID string, pl
 }
 
 // AcceptGuildInvite is the resolver for the acceptGuildInvite field.
-func (r *mutationResolver) AcceptGuildInvite(ctx context.Context, guildID string, playerID string) (bool, error) {
+func (r *mutationResolver) AcceptGuildInvite(ctx context.Context, guildID string, playerID string, force *bool) (bool, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return false, err
+	}
 	gID, err := strconv.Atoi(guildID)
 	if err != nil {
 		return false, fmt.Errorf("invalid guild id: %w", err)
@@ -166,7 +681,11 @@ func (r *mutationResolver) AcceptGuildInvite(ctx context.Context, guildID string
 }
 
 // BidHouse is the resolver for the bidHouse field.
-func (r *mutationResolver) BidHouse(ctx context.Context, houseID string, playerID string, bidAmount int) (*models.House, error) {
+func (r *mutationResolver) BidHouse(ctx context.Context, houseID string, playerID string, bidAmount int, force *bool) (*models.House, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
 	hID, err := strconv.Atoi(houseID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid house id: %w", err)
@@ -179,12 +698,86 @@ func (r *mutationResolver) BidHouse(ctx context.Context, houseID string, playerI
 }
 
 // CreateMarketOffer is the resolver for the createMarketOffer field.
-func (r *mutationResolver) CreateMarketOffer(ctx context.Context, input models.CreateMarketOfferInput) (*models.MarketOffer, error) {
+func (r *mutationResolver) CreateMarketOffer(ctx context.Context, input models.CreateMarketOfferInput, force *bool) (*models.MarketOffer, error) {
+	ctx, err := onlineGuardContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
 	return r.MarketRepository.CreateOffer(ctx, input)
 }
 
+// BanIP is the resolver for the banIp field.
+func (r *mutationResolver) BanIP(ctx context.Context, input models.BanIpInput) (*models.IpBan, error) {
+	moderatorID, err := r.moderator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input.BannedBy = moderatorID
+	ban, err := r.IpBanRepository.Create(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "IP banned", "ip", input.IP, "reason", input.Reason)
+	return ban, nil
+}
+
+// UnbanIP is the resolver for the unbanIp field.
+func (r *mutationResolver) UnbanIP(ctx context.Context, ip string) (bool, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return false, err
+	}
+	unbanned, err := r.IpBanRepository.Delete(ctx, ip)
+	if err != nil {
+		return false, err
+	}
+	if unbanned {
+		slog.InfoContext(ctx, "IP unbanned", "ip", ip)
+	}
+	return unbanned, nil
+}
+
+// NamelockPlayer is the resolver for the namelockPlayer field.
+func (r *mutationResolver) NamelockPlayer(ctx context.Context, playerID string, reason string) (*models.PlayerNamelock, error) {
+	moderatorID, err := r.moderator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	namelock, err := r.PlayerNamelockRepository.Create(ctx, pID, reason, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Player namelocked", "player_id", pID, "reason", reason)
+	return namelock, nil
+}
+
+// ResolveNamelock is the resolver for the resolveNamelock field.
+func (r *mutationResolver) ResolveNamelock(ctx context.Context, playerID string, newName string, force *bool) (*models.Player, error) {
+	ctx, err := staffWriteContext(ctx, force)
+	if err != nil {
+		return nil, err
+	}
+	pID, err := strconv.Atoi(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid player id: %w", err)
+	}
+	if err := r.NameValidator.Validate(ctx, newName, pID); err != nil {
+		return nil, err
+	}
+	if err := r.PlayerNamelockRepository.Resolve(ctx, pID, newName); err != nil {
+		return nil, err
+	}
+	return r.PlayerRepository.GetByID(ctx, pID)
+}
+
 // Account is the resolver for the account field.
 func (r *playerResolver) Account(ctx context.Context, obj *models.Player) (*models.Account, error) {
+	if err := auth.RequireOwner(ctx, obj.AccountID); err != nil {
+		return nil, err
+	}
 	return r.AccountRepository.GetByID(ctx, obj.AccountID)
 }
 
@@ -198,22 +791,98 @@ func (r *playerResolver) Deaths(ctx context.Context, obj *models.Player) ([]*mod
 	return r.PlayerDeathRepository.GetByPlayerID(ctx, obj.ID)
 }
 
+// Storage is the resolver for the storage field.
+func (r *playerResolver) Storage(ctx context.Context, obj *models.Player, keys []int, rangeArg *models.StorageRange) ([]*models.PlayerStorage, error) {
+	return r.PlayerStorageRepository.Find(ctx, obj.ID, keys, rangeArg)
+}
+
+// Quests is the resolver for the quests field.
+func (r *playerResolver) Quests(ctx context.Context, obj *models.Player) ([]*models.PlayerQuest, error) {
+	keys := r.QuestCatalog.StorageKeys()
+	if len(keys) == 0 {
+		return []*models.PlayerQuest{}, nil
+	}
+	storage, err := r.PlayerStorageRepository.Find(ctx, obj.ID, keys, nil)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[int]int, len(storage))
+	for _, s := range storage {
+		values[s.Key] = s.Value
+	}
+	return r.QuestCatalog.Evaluate(values), nil
+}
+
+// Outfits is the resolver for the outfits field.
+func (r *playerResolver) Outfits(ctx context.Context, obj *models.Player) ([]*models.PlayerOutfit, error) {
+	storage, err := r.PlayerStorageRepository.Find(ctx, obj.ID, nil, &models.StorageRange{
+		From: models.OutfitStorageStart,
+		To:   models.OutfitStorageStart + models.OutfitStorageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.OutfitCatalog.PlayerOutfits(obj.Sex, storage), nil
+}
+
+// Mounts is the resolver for the mounts field.
+func (r *playerResolver) Mounts(ctx context.Context, obj *models.Player) ([]*models.Mount, error) {
+	storage, err := r.PlayerStorageRepository.Find(ctx, obj.ID, nil, &models.StorageRange{
+		From: models.MountStorageStart,
+		To:   models.MountStorageStart + models.MountStorageSize - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.MountCatalog.PlayerMounts(storage), nil
+}
+
 // Guild is the resolver for the guild field.
 func (r *playerResolver) Guild(ctx context.Context, obj *models.Player) (*models.GuildMembership, error) {
 	return r.GuildRepository.GetMembershipByPlayerID(ctx, obj.ID)
 }
 
+// Namelock is the resolver for the namelock field.
+func (r *playerResolver) Namelock(ctx context.Context, obj *models.Player) (*models.PlayerNamelock, error) {
+	namelock, err := r.PlayerNamelockRepository.GetByPlayerID(ctx, obj.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return namelock, err
+}
+
+// NameHistory is the resolver for the nameHistory field.
+func (r *playerResolver) NameHistory(ctx context.Context, obj *models.Player) ([]*models.PlayerNameChange, error) {
+	return r.PlayerRepository.GetNameHistory(ctx, obj.ID)
+}
+
+// Player is the resolver for the player field.
+func (r *playerNamelockResolver) Player(ctx context.Context, obj *models.PlayerNamelock) (*models.Player, error) {
+	return r.PlayerRepository.GetByID(ctx, obj.PlayerID)
+}
+
+// NamelockedBy is the resolver for the namelockedBy field.
+func (r *playerNamelockResolver) NamelockedBy(ctx context.Context, obj *models.PlayerNamelock) (*models.Player, error) {
+	return r.PlayerRepository.GetByID(ctx, obj.NamelockedBy)
+}
+
 // Account is the resolver for the account field.
 func (r *queryResolver) Account(ctx context.Context, id string) (*models.Account, error) {
 	accountID, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, fmt.Errorf("invalid account id: %w", err)
 	}
+	if err := auth.RequireOwner(ctx, accountID); err != nil {
+		return nil, err
+	}
 	return r.AccountRepository.GetByID(ctx, accountID)
 }
 
 // Accounts is the resolver for the accounts field.
 func (r *queryResolver) Accounts(ctx context.Context, limit *int) ([]*models.Account, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
 	queryLimit := 10
 	if limit != nil {
 		queryLimit = *limit
@@ -221,6 +890,18 @@ func (r *queryResolver) Accounts(ctx context.Context, limit *int) ([]*models.Acc
 	return r.AccountRepository.GetAll(ctx, queryLimit)
 }
 
+// SearchAccounts is the resolver for the searchAccounts field.
+func (r *queryResolver) SearchAccounts(ctx context.Context, query string, limit *int) ([]*models.Account, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	queryLimit := 20
+	if limit != nil {
+		queryLimit = *limit
+	}
+	return r.AccountRepository.Search(ctx, query, queryLimit)
+}
+
 // Player is the resolver for the player field.
 func (r *queryResolver) Player(ctx context.Context, id string) (*models.Player, error) {
 	playerID, err := strconv.Atoi(id)
@@ -230,13 +911,34 @@ func (r *queryResolver) Player(ctx context.Context, id string) (*models.Player,
 	return r.PlayerRepository.GetByID(ctx, playerID)
 }
 
+// PlayerByName is the resolver for the playerByName field.
+func (r *queryResolver) PlayerByName(ctx context.Context, name string) (*models.Player, error) {
+	player, err := r.PlayerRepository.GetByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return player, err
+}
+
+// CharacterProfile is the resolver for the characterProfile field.
+func (r *queryResolver) CharacterProfile(ctx context.Context, name string) (*models.CharacterProfile, error) {
+	player, err := r.PlayerRepository.GetByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.CharacterProfile{Player: player}, nil
+}
+
 // Players is the resolver for the players field.
 func (r *queryResolver) Players(ctx context.Context, accountID string) ([]*models.Player, error) {
 	accID, err := strconv.Atoi(accountID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid account id: %w", err)
 	}
-	return r.PlayerRepository.GetByAccountID(ctx, accID)
+	return r.accountPlayers(ctx, accID)
 }
 
 // PlayersOnline is the resolver for the playersOnline field.
@@ -257,6 +959,34 @@ func (r *queryResolver) PlayersOnline(ctx context.Context) ([]*models.Player, er
 	return players, nil
 }
 
+// SearchPlayers is the resolver for the searchPlayers field.
+func (r *queryResolver) SearchPlayers(ctx context.Context, query string, vocation *int, levelRange *models.LevelRange, online *bool, first *int, after *string) (*models.PlayerConnection, error) {
+	return r.PlayerRepository.Search(ctx, models.PlayerSearch{
+		Query:      query,
+		Vocation:   vocation,
+		LevelRange: levelRange,
+		Online:     online,
+		First:      first,
+		After:      after,
+	})
+}
+
+// PlayersByStorage is the resolver for the playersByStorage field.
+func (r *queryResolver) PlayersByStorage(ctx context.Context, key int, value int, op *models.StorageComparison, limit *int) ([]*models.Player, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	comparison := models.StorageComparisonEq
+	if op != nil {
+		comparison = *op
+	}
+	queryLimit := 100
+	if limit != nil {
+		queryLimit = *limit
+	}
+	return r.PlayerRepository.GetByStorage(ctx, key, value, comparison, queryLimit)
+}
+
 // Town is the resolver for the town field.
 func (r *queryResolver) Town(ctx context.Context, id string) (*models.Town, error) {
 	townID, err := strconv.Atoi(id)
@@ -334,6 +1064,64 @@ func (r *queryResolver) MarketHistory(ctx context.Context, playerID string) ([]*
 	return r.MarketRepository.GetHistory(ctx, pID)
 }
 
+// Quests is the resolver for the quests field.
+func (r *queryResolver) Quests(ctx context.Context) ([]*models.Quest, error) {
+	return r.QuestCatalog.Quests, nil
+}
+
+// Outfits is the resolver for the outfits field.
+func (r *queryResolver) Outfits(ctx context.Context, sex *int) ([]*models.Outfit, error) {
+	return r.OutfitCatalog.ForSex(sex), nil
+}
+
+// Mounts is the resolver for the mounts field.
+func (r *queryResolver) Mounts(ctx context.Context) ([]*models.Mount, error) {
+	return r.MountCatalog.Mounts, nil
+}
+
+// IPBans is the resolver for the ipBans field.
+func (r *queryResolver) IPBans(ctx context.Context) ([]*models.IpBan, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	return r.IpBanRepository.GetAll(ctx)
+}
+
+// IPBan is the resolver for the ipBan field.
+func (r *queryResolver) IPBan(ctx context.Context, ip string) (*models.IpBan, error) {
+	if err := auth.RequireStaff(ctx); err != nil {
+		return nil, err
+	}
+	ban, err := r.IpBanRepository.GetByIP(ctx, ip)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return ban, err
+}
+
+// ServerStatus is the resolver for the serverStatus field.
+func (r *queryResolver) ServerStatus(ctx context.Context) (*status.Status, error) {
+	serverStatus, err := r.StatusMonitor.Status(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Game server status check failed", "error", err)
+	}
+	return serverStatus, nil
+}
+
+// ServerInfo is the resolver for the serverInfo field.
+func (r *queryResolver) ServerInfo(ctx context.Context) (*models.ServerConfig, error) {
+	return r.ServerConfigRepository.Get(ctx)
+}
+
+// OnlineHistory is the resolver for the onlineHistory field.
+func (r *queryResolver) OnlineHistory(ctx context.Context, hours *int) ([]*models.OnlineSample, error) {
+	historyHours := 24
+	if hours != nil {
+		historyHours = *hours
+	}
+	return r.OnlineHistoryRepository.GetHistory(ctx, historyHours)
+}
+
 // Player is the resolver for the player field.
 func (r *vipEntryResolver) Player(ctx context.Context, obj *models.VipEntry) (*models.Player, error) {
 	return r.PlayerRepository.GetByID(ctx, obj.PlayerID)
@@ -345,6 +1133,9 @@ func (r *Resolver) Account() AccountResolver { return &accountResolver{r} }
 // AccountBan returns AccountBanResolver implementation.
 func (r *Resolver) AccountBan() AccountBanResolver { return &accountBanResolver{r} }
 
+// CharacterProfile returns CharacterProfileResolver implementation.
+func (r *Resolver) CharacterProfile() CharacterProfileResolver { return &characterProfileResolver{r} }
+
 // Guild returns GuildResolver implementation.
 func (r *Resolver) Guild() GuildResolver { return &guildResolver{r} }
 
@@ -363,6 +1154,9 @@ func (r *Resolver) GuildWar() GuildWarResolver { return &guildWarResolver{r} }
 // House returns HouseResolver implementation.
 func (r *Resolver) House() HouseResolver { return &houseResolver{r} }
 
+// IpBan returns IpBanResolver implementation.
+func (r *Resolver) IpBan() IpBanResolver { return &ipBanResolver{r} }
+
 // MarketHistory returns MarketHistoryResolver implementation.
 func (r *Resolver) MarketHistory() MarketHistoryResolver { return &marketHistoryResolver{r} }
 
@@ -375,6 +1169,9 @@ func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 // Player returns PlayerResolver implementation.
 func (r *Resolver) Player() PlayerResolver { return &playerResolver{r} }
 
+// PlayerNamelock returns PlayerNamelockResolver implementation.
+func (r *Resolver) PlayerNamelock() PlayerNamelockResolver { return &playerNamelockResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
@@ -383,15 +1180,18 @@ func (r *Resolver) VipEntry() VipEntryResolver { return &vipEntryResolver{r} }
 
 type accountResolver struct{ *Resolver }
 type accountBanResolver struct{ *Resolver }
+type characterProfileResolver struct{ *Resolver }
 type guildResolver struct{ *Resolver }
 type guildInviteResolver struct{ *Resolver }
 type guildMembershipResolver struct{ *Resolver }
 type guildRankResolver struct{ *Resolver }
 type guildWarResolver struct{ *Resolver }
 type houseResolver struct{ *Resolver }
+type ipBanResolver struct{ *Resolver }
 type marketHistoryResolver struct{ *Resolver }
 type marketOfferResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type playerResolver struct{ *Resolver }
+type playerNamelockResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type vipEntryResolver struct{ *Resolver }
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryResolver_ServerStatus_Offline(t *testing.T) {
+	resolver, _, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+	resolver.StatusMonitor = status.NewMonitor(status.NewClient(addr), time.Minute)
+
+	serverStatus, err := resolver.Query().ServerStatus(context.Background())
+
+	require.NoError(t, err, "an unreachable server is reported offline, not as an error")
+	assert.False(t, serverStatus.Online)
+	assert.Nil(t, serverStatus.Info)
+}
+
+func TestQueryResolver_OnlineHistory_DefaultHours(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM api_online_history").
+		WithArgs(24 * 60 * 60).
+		WillReturnRows(sqlmock.NewRows([]string{"sampled_at", "players_online"}).AddRow(1700000000, 10))
+
+	samples, err := resolver.Query().OnlineHistory(context.Background(), nil)
+
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
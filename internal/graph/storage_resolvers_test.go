@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func staffContext() context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Staff: true})
+}
+
+func accountContext(accountID int) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{AccountID: accountID, SessionToken: "token"})
+}
+
+func TestMutationResolver_SetPlayerStorage_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	storage, err := resolver.Mutation().SetPlayerStorage(context.Background(), "1", 50000, 3, nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, storage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_IncrementPlayerStorage(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO player_storage").
+		WithArgs(1, 50000, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT value FROM player_storage").
+		WithArgs(1, 50000).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(1))
+	mock.ExpectCommit()
+
+	storage, err := resolver.Mutation().IncrementPlayerStorage(staffContext(), "1", 50000, nil, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, storage.Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResolver_PlayersByStorage(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("WHERE s.`key` = \\? AND s.value = \\? ORDER BY p.name LIMIT \\?").
+		WithArgs(50000, 3, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Quester"))
+
+	players, err := resolver.Query().PlayersByStorage(staffContext(), 50000, 3, nil, nil)
+
+	require.NoError(t, err)
+	assert.Len(t, players, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResolver_PlayersByStorage_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	players, err := resolver.Query().PlayersByStorage(accountContext(1), 50000, 3, nil, nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.Nil(t, players)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerResolver_Storage(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? AND \\(`key` BETWEEN \\? AND \\?\\)").
+		WithArgs(1, 10001000, 10001500).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id", "key", "value"}).AddRow(1, 10001001, 8519683))
+
+	storage, err := resolver.Player().Storage(context.Background(), &models.Player{ID: 1}, nil,
+		&models.StorageRange{From: 10001000, To: 10001500})
+
+	require.NoError(t, err)
+	assert.Len(t, storage, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerResolver_Quests(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	resolver.QuestCatalog = &models.QuestCatalog{Quests: []*models.Quest{{
+		ID: 1, Name: "Example Quest", StartStorageID: 1001, StartStorageValue: 1,
+		Missions: []*models.QuestMission{{
+			Name: "Example Mission", StorageID: 1001, StartValue: 1, EndValue: 2,
+			States: map[int]string{1: "Started", 2: "Done"},
+		}},
+	}}}
+
+	mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? AND \\(`key` IN \\(\\?\\)\\)").
+		WithArgs(1, 1001).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id", "key", "value"}).AddRow(1, 1001, 2))
+
+	quests, err := resolver.Player().Quests(context.Background(), &models.Player{ID: 1})
+
+	require.NoError(t, err)
+	require.Len(t, quests, 1)
+	assert.Equal(t, models.QuestStatusCompleted, quests[0].Status)
+	assert.Equal(t, "Done", quests[0].Missions[0].Description)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerResolver_Quests_NoCatalog(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	quests, err := resolver.Player().Quests(context.Background(), &models.Player{ID: 1})
+
+	require.NoError(t, err)
+	assert.Empty(t, quests)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerResolver_Mounts(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	resolver.MountCatalog = &models.MountCatalog{Mounts: []*models.Mount{{ID: 1, Name: "Widow Queen"}}}
+
+	mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? AND \\(`key` BETWEEN \\? AND \\?\\)").
+		WithArgs(1, models.MountStorageStart, models.MountStorageStart+models.MountStorageSize-1).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id", "key", "value"}).AddRow(1, models.MountStorageStart, 1))
+
+	mounts, err := resolver.Player().Mounts(context.Background(), &models.Player{ID: 1})
+
+	require.NoError(t, err)
+	require.Len(t, mounts, 1)
+	assert.Equal(t, "Widow Queen", mounts[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_GrantOutfit_RequiresStaff(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	ok, err := resolver.Mutation().GrantOutfit(context.Background(), "1", 268, nil, nil)
+
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMutationResolver_GrantMount(t *testing.T) {
+	resolver, mock, cleanup := setupTestResolver(t)
+	defer cleanup()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("INSERT INTO player_storage").
+		WithArgs(1, models.MountStorageStart, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ok, err := resolver.Mutation().GrantMount(staffContext(), "1", "1", nil)
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,75 @@
+// Package health serves the liveness and readiness probes used by
+// orchestrators to restart the API and to route traffic to it.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Liveness reports that the process is up and serving HTTP. It checks
+// nothing else, so a database outage doesn't get the API restarted.
+func Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Check is one dependency that must be healthy for the API to be ready.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Readiness reports whether every check passes, and stops reporting ready
+// once Drain is called so traffic moves away before shutdown.
+type Readiness struct {
+	Timeout time.Duration
+
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewReadiness(checks ...Check) *Readiness {
+	return &Readiness{Timeout: 3 * time.Second, checks: checks}
+}
+
+// Drain marks the API as shutting down.
+func (h *Readiness) Drain() {
+	h.draining.Store(true)
+}
+
+// ServeHTTP runs the checks in order and responds 503 if any fails. Failures
+// are logged rather than returned, since they can name hosts and users.
+func (h *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.Timeout)
+	defer cancel()
+
+	status, code := "ok", http.StatusOK
+	results := make(map[string]string, len(h.checks))
+	for _, check := range h.checks {
+		if err := check.Run(ctx); err != nil {
+			slog.WarnContext(ctx, "Readiness check failed", "check", check.Name, "error", err)
+			results[check.Name] = "failed"
+			status, code = "unavailable", http.StatusServiceUnavailable
+			continue
+		}
+		results[check.Name] = "ok"
+	}
+
+	writeJSON(w, code, map[string]any{"status": status, "checks": results})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
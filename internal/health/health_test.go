@@ -0,0 +1,59 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, h http.Handler) (int, map[string]any) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestLiveness(t *testing.T) {
+	code, body := probe(t, http.HandlerFunc(Liveness))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+}
+
+func TestReadiness(t *testing.T) {
+	dbErr := errors.New("dial tcp 10.0.0.5:3306: connection refused")
+	var failing bool
+	h := NewReadiness(
+		Check{Name: "database", Run: func(ctx context.Context) error {
+			if failing {
+				return dbErr
+			}
+			return nil
+		}},
+		Check{Name: "db_version", Run: func(ctx context.Context) error { return nil }},
+	)
+
+	code, body := probe(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok", "db_version": "ok"}, body["checks"])
+
+	failing = true
+	code, body = probe(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", body["status"])
+	assert.Equal(t, map[string]any{"database": "failed", "db_version": "ok"}, body["checks"])
+
+	failing = false
+	h.Drain()
+	code, body = probe(t, h)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", body["status"])
+}
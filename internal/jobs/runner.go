@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is a task the Runner executes every Interval. A job whose Interval is
+// 0 or less is disabled.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs background jobs until it is stopped.
+type Runner struct {
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Add registers a job. Jobs added after Start are not run, and disabled
+// jobs are skipped.
+func (r *Runner) Add(job Job) {
+	if job.Interval <= 0 {
+		slog.Info("Job disabled", "job", job.Name)
+		return
+	}
+	r.jobs = append(r.jobs, job)
+}
+
+// Start runs every job once immediately and then on its interval.
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func(job Job) {
+			defer r.wg.Done()
+			r.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Job failed", "job", job.Name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner_RunsJobsUntilStopped(t *testing.T) {
+	var runs atomic.Int32
+
+	runner := NewRunner()
+	runner.Add(Job{
+		Name:     "count",
+		Interval: 5 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	runner.Start(context.Background())
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+	runner.Stop()
+
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestRunner_SkipsDisabledJobs(t *testing.T) {
+	var runs atomic.Int32
+
+	runner := NewRunner()
+	runner.Add(Job{
+		Name:     "disabled",
+		Interval: 0,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	runner.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	runner.Stop()
+
+	assert.Zero(t, runs.Load())
+}
+
+func TestRunner_StopWithoutStart(t *testing.T) {
+	runner := NewRunner()
+
+	assert.NotPanics(t, runner.Stop)
+}
@@ -0,0 +1,105 @@
+// Package limits rejects GraphQL operations that are too deep or too costly
+// before any of their resolvers run.
+package limits
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// Depth is a gqlgen extension rejecting operations that nest fields more
+// than Max levels deep. Introspection fields are not counted. Zero means no
+// limit.
+type Depth struct {
+	Max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Depth{}
+
+func (Depth) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (Depth) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d Depth) MutateOperationContext(ctx context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	if d.Max <= 0 || oc.Operation == nil {
+		return nil
+	}
+
+	if depth := selectionDepth(oc.Operation.SelectionSet); depth > d.Max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.Max)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+	return nil
+}
+
+// selectionDepth returns how many levels of fields a selection set nests.
+// Fragments add their fields at the level they are spread into.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			depth = max(depth, 1+selectionDepth(s.SelectionSet))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(s.SelectionSet))
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				depth = max(depth, selectionDepth(s.Definition.SelectionSet))
+			}
+		}
+	}
+	return depth
+}
+
+// Budget is the complexity an operation may have, by who sends it. Zero
+// means no limit.
+type Budget struct {
+	Anonymous int
+	Account   int
+	Staff     int
+}
+
+// Limit returns the budget for the caller in ctx.
+func (b Budget) Limit(ctx context.Context, _ *graphql.OperationContext) int {
+	limit := b.Anonymous
+	if principal := auth.FromContext(ctx); principal != nil {
+		if principal.Staff {
+			limit = b.Staff
+		} else {
+			limit = b.Account
+		}
+	}
+
+	if limit <= 0 {
+		return math.MaxInt
+	}
+	return limit
+}
+
+// Complexity returns a gqlgen extension rejecting operations whose
+// complexity, as calculated by the schema's complexity functions, is over
+// the caller's budget.
+func (b Budget) Complexity() *extension.ComplexityLimit {
+	return &extension.ComplexityLimit{Func: b.Limit}
+}
@@ -0,0 +1,112 @@
+package limits
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newTestServer(t *testing.T) (*handler.Server, sqlmock.Sqlmock) {
+	db, mock, err := models.NewMockDB()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{
+		Resolvers:  graph.NewResolver(db),
+		Complexity: graph.NewComplexity(),
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.Use(Depth{Max: 4})
+	srv.Use(Budget{Anonymous: 100, Account: 1000, Staff: 0}.Complexity())
+	return srv, mock
+}
+
+func query(t *testing.T, srv http.Handler, principal *auth.Principal, body string) response {
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestDepth(t *testing.T) {
+	srv, mock := newTestServer(t)
+
+	resp := query(t, srv, nil, `{"query":"{ guilds { members { player { account { name } } } } }"}`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "operation has depth 5, which exceeds the limit of 4", resp.Errors[0].Message)
+	assert.Equal(t, "DEPTH_LIMIT_EXCEEDED", resp.Errors[0].Extensions["code"])
+
+	// Fragments count at the level they are spread into.
+	resp = query(t, srv, nil, `{"query":"query { towns { ...T } } fragment T on Town { id ... on Town { name } }"}`)
+	assert.NotEqual(t, "DEPTH_LIMIT_EXCEEDED", codeOf(resp))
+
+	resp = query(t, srv, nil, `{"query":"query { towns { ...T } } fragment T on Town { __typename } "}`)
+	assert.NotEqual(t, "DEPTH_LIMIT_EXCEEDED", codeOf(resp))
+
+	assert.NoError(t, mock.ExpectationsWereMet(), "rejected operations run no SQL")
+}
+
+func TestBudget(t *testing.T) {
+	srv, mock := newTestServer(t)
+	// 1 + 200 online players * 1 field
+	const online = `{"query":"{ playersOnline { name } }"}`
+
+	resp := query(t, srv, nil, online)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "operation has complexity 201, which exceeds the limit of 100", resp.Errors[0].Message)
+	assert.Equal(t, "COMPLEXITY_LIMIT_EXCEEDED", resp.Errors[0].Extensions["code"])
+	assert.NoError(t, mock.ExpectationsWereMet(), "rejected operations run no SQL")
+
+	resp = query(t, srv, &auth.Principal{AccountID: 1}, online)
+	assert.NotEqual(t, "COMPLEXITY_LIMIT_EXCEEDED", codeOf(resp))
+
+	// Page sizes scale list costs: 1 + 1000 * 1.
+	resp = query(t, srv, &auth.Principal{AccountID: 1}, `{"query":"{ accounts(limit: 1000) { name } }"}`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "operation has complexity 1001, which exceeds the limit of 1000", resp.Errors[0].Message)
+}
+
+func TestBudget_Limit(t *testing.T) {
+	b := Budget{Anonymous: 10, Account: 20, Staff: 0}
+	ctx := context.Background()
+
+	assert.Equal(t, 10, b.Limit(ctx, nil))
+	assert.Equal(t, 20, b.Limit(auth.WithPrincipal(ctx, &auth.Principal{AccountID: 3}), nil))
+	assert.Greater(t, b.Limit(auth.WithPrincipal(ctx, &auth.Principal{Staff: true, Name: "alice"}), nil), 1_000_000_000,
+		"a zero budget is unlimited")
+}
+
+func codeOf(resp response) string {
+	if len(resp.Errors) == 0 {
+		return ""
+	}
+	code, _ := resp.Errors[0].Extensions["code"].(string)
+	return code
+}
@@ -0,0 +1,93 @@
+// Package logging sets up JSON logging that tags each record with the
+// request, GraphQL operation and principal it was logged for.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a JSON logger writing records at level and above to w.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// ParseLevel parses a level name such as "debug" or "warn", falling back to
+// info.
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// contextHandler adds request_id, trace_id, operation, account_id and staff
+// to records logged with a request's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := middleware.GetReqID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	if graphql.HasOperationContext(ctx) {
+		if oc := graphql.GetOperationContext(ctx); oc.OperationName != "" {
+			r.AddAttrs(slog.String("operation", oc.OperationName))
+		} else if oc.Operation != nil && oc.Operation.Name != "" {
+			r.AddAttrs(slog.String("operation", oc.Operation.Name))
+		}
+	}
+	if principal := auth.FromContext(ctx); principal != nil {
+		if principal.Staff {
+			r.AddAttrs(slog.String("staff", principal.Name))
+		} else {
+			r.AddAttrs(slog.Int("account_id", principal.AccountID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
+
+// Middleware logs each request once it has been served. It should run after
+// auth.Middleware so the record names the caller.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		slog.InfoContext(r.Context(), "Request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", auth.ClientIP(r.Context()),
+		)
+	})
+}
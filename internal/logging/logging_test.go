@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	return record
+}
+
+func TestLogger_ContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "host/abc-000001")
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{AccountID: 7})
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{OperationName: "Profile"})
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  trace.SpanID{0, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	}))
+
+	logger.InfoContext(ctx, "hello", "key", "value")
+
+	record := decode(t, &buf)
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "value", record["key"])
+	assert.Equal(t, "host/abc-000001", record["request_id"])
+	assert.Equal(t, "Profile", record["operation"])
+	assert.Equal(t, float64(7), record["account_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+}
+
+func TestLogger_Staff(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo).With("component", "test")
+
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Staff: true, Name: "alice"})
+	logger.WarnContext(ctx, "careful")
+
+	record := decode(t, &buf)
+	assert.Equal(t, "alice", record["staff"])
+	assert.Equal(t, "test", record["component"])
+	assert.NotContains(t, record, "account_id")
+}
+
+func TestLogger_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, ParseLevel("warn"))
+
+	logger.Info("dropped")
+	assert.Empty(t, buf.String())
+
+	assert.Equal(t, slog.LevelDebug, ParseLevel("debug"))
+	assert.Equal(t, slog.LevelInfo, ParseLevel("loud"))
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(New(&buf, slog.LevelInfo))
+	defer slog.SetDefault(previous)
+
+	handler := middleware.RequestID(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/query", nil))
+
+	record := decode(t, &buf)
+	assert.Equal(t, "Request served", record["msg"])
+	assert.Equal(t, "POST", record["method"])
+	assert.Equal(t, "/query", record["path"])
+	assert.Equal(t, float64(http.StatusTeapot), record["status"])
+	assert.Equal(t, float64(15), record["bytes"])
+	assert.NotEmpty(t, record["request_id"])
+}
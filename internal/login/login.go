@@ -0,0 +1,372 @@
+// Package login serves the JSON login protocol that Tibia 11 and later
+// clients speak to their login URL (login.php) instead of the old binary
+// login server.
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/ratelimit"
+)
+
+// Error codes the client understands. Code 6 makes it ask for the
+// authenticator token; anything else is shown as a login error.
+const (
+	errorCodeLogin     = 3
+	errorCodeTwoFactor = 6
+)
+
+// PvP types as the client numbers them.
+const (
+	PvpTypeOpen     = 0
+	PvpTypeOptional = 1
+	PvpTypeHardcore = 2
+)
+
+// World is a game world offered on the character list.
+type World struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Location string `json:"location"`
+	PvpType  int    `json:"pvpType"`
+}
+
+// vocationNames are the vocations in TFS's data/XML/vocations.xml.
+var vocationNames = map[int]string{
+	0: "None",
+	1: "Sorcerer",
+	2: "Druid",
+	3: "Paladin",
+	4: "Knight",
+	5: "Master Sorcerer",
+	6: "Elder Druid",
+	7: "Royal Paladin",
+	8: "Elite Knight",
+}
+
+type request struct {
+	Type        string `json:"type"`
+	Email       string `json:"email"`
+	AccountName string `json:"accountname"`
+	Password    string `json:"password"`
+	Token       string `json:"token"`
+}
+
+type errorResponse struct {
+	ErrorCode    int    `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+type session struct {
+	SessionKey                    string `json:"sessionkey"`
+	LastLoginTime                 int64  `json:"lastlogintime"`
+	IsPremium                     bool   `json:"ispremium"`
+	PremiumUntil                  int64  `json:"premiumuntil"`
+	Status                        string `json:"status"`
+	ReturnerNotification          bool   `json:"returnernotification"`
+	ShowRewardNews                bool   `json:"showrewardnews"`
+	IsReturner                    bool   `json:"isreturner"`
+	FpsTracking                   bool   `json:"fpstracking"`
+	OptionTracking                bool   `json:"optiontracking"`
+	TournamentTicketPurchaseState int    `json:"tournamentticketpurchasestate"`
+	EmailCodeRequest              bool   `json:"emailcoderequest"`
+}
+
+type world struct {
+	ID                         int    `json:"id"`
+	Name                       string `json:"name"`
+	ExternalAddress            string `json:"externaladdress"`
+	ExternalPort               int    `json:"externalport"`
+	ExternalAddressProtected   string `json:"externaladdressprotected"`
+	ExternalPortProtected      int    `json:"externalportprotected"`
+	ExternalAddressUnprotected string `json:"externaladdressunprotected"`
+	ExternalPortUnprotected    int    `json:"externalportunprotected"`
+	PreviewState               int    `json:"previewstate"`
+	Location                   string `json:"location"`
+	AntiCheatProtection        bool   `json:"anticheatprotection"`
+	PvpType                    int    `json:"pvptype"`
+	IsTournamentWorld          bool   `json:"istournamentworld"`
+	RestrictedStore            bool   `json:"restrictedstore"`
+	CurrentTournamentPhase     int    `json:"currenttournamentphase"`
+}
+
+type character struct {
+	WorldID                          int    `json:"worldid"`
+	Name                             string `json:"name"`
+	IsMale                           bool   `json:"ismale"`
+	Tutorial                         bool   `json:"tutorial"`
+	Level                            int    `json:"level"`
+	Vocation                         string `json:"vocation"`
+	OutfitID                         int    `json:"outfitid"`
+	HeadColor                        int    `json:"headcolor"`
+	TorsoColor                       int    `json:"torsocolor"`
+	LegsColor                        int    `json:"legscolor"`
+	DetailColor                      int    `json:"detailcolor"`
+	AddonsFlags                      int    `json:"addonsflags"`
+	IsHidden                         bool   `json:"ishidden"`
+	IsTournamentParticipant          bool   `json:"istournamentparticipant"`
+	IsMainCharacter                  bool   `json:"ismaincharacter"`
+	DailyRewardState                 int    `json:"dailyrewardstate"`
+	RemainingDailyTournamentPlaytime int    `json:"remainingdailytournamentplaytime"`
+}
+
+type playData struct {
+	Worlds     []world     `json:"worlds"`
+	Characters []character `json:"characters"`
+}
+
+type loginResponse struct {
+	Session  session  `json:"session"`
+	PlayData playData `json:"playdata"`
+}
+
+type cacheInfoResponse struct {
+	PlayersOnline        int `json:"playersonline"`
+	TwitchStreams        int `json:"twitchstreams"`
+	TwitchViewer         int `json:"twitchviewer"`
+	GamingYoutubeStreams int `json:"gamingyoutubestreams"`
+	GamingYoutubeViewer  int `json:"gamingyoutubeviewer"`
+}
+
+type eventScheduleResponse struct {
+	EventList           []any `json:"eventlist"`
+	LastUpdateTimestamp int64 `json:"lastupdatetimestamp"`
+}
+
+// Handler answers login.php requests. TFS keeps one world per database, so
+// every character is listed on the first world.
+type Handler struct {
+	Accounts *models.AccountRepository
+	Players  *models.PlayerRepository
+	Bans     *models.AccountBanRepository
+	Worlds   []World
+
+	// Limiter, when set, throttles login attempts under the "login" limit
+	// shared with the GraphQL login mutation
+	Limiter *ratelimit.Limiter
+
+	now func() time.Time
+}
+
+func NewHandler(accounts *models.AccountRepository, players *models.PlayerRepository, bans *models.AccountBanRepository, worlds []World) *Handler {
+	return &Handler{
+		Accounts: accounts,
+		Players:  players,
+		Bans:     bans,
+		Worlds:   worlds,
+		now:      time.Now,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, errorCodeLogin, "Invalid request.")
+		return
+	}
+
+	switch strings.ToLower(req.Type) {
+	case "login":
+		if h.Limiter != nil {
+			var limitErr *ratelimit.Error
+			if err := h.Limiter.Allow(r.Context(), "login"); errors.As(err, &limitErr) {
+				w.Header().Set("Retry-After", strconv.Itoa(limitErr.RetryAfterSeconds()))
+				writeError(w, errorCodeLogin, fmt.Sprintf("Too many login attempts. Please try again in %d seconds.", limitErr.RetryAfterSeconds()))
+				return
+			}
+		}
+		h.login(r.Context(), w, req)
+	case "cacheinfo":
+		h.cacheInfo(r.Context(), w)
+	case "eventschedule":
+		writeJSON(w, eventScheduleResponse{EventList: []any{}, LastUpdateTimestamp: h.now().Unix()})
+	default:
+		writeError(w, errorCodeLogin, "Unrecognized request type.")
+	}
+}
+
+func (h *Handler) login(ctx context.Context, w http.ResponseWriter, req request) {
+	var account *models.Account
+	var err error
+	if req.Email != "" {
+		account, err = h.Accounts.CheckLoginByEmail(ctx, req.Email, req.Password)
+	} else {
+		account, err = h.Accounts.CheckLogin(ctx, req.AccountName, req.Password)
+	}
+	if errors.Is(err, models.ErrInvalidLogin) {
+		writeError(w, errorCodeLogin, "Account name or password is not correct.")
+		return
+	}
+	if err != nil {
+		h.internalError(ctx, w, "check login", err)
+		return
+	}
+
+	now := h.now()
+	if account.HasTwoFactor() {
+		if req.Token == "" {
+			writeError(w, errorCodeTwoFactor, "Two-factor token required for authentication.")
+			return
+		}
+		if !account.CheckTwoFactorToken(req.Token, now) {
+			writeError(w, errorCodeTwoFactor, "Two-factor token is not correct.")
+			return
+		}
+	}
+
+	ban, err := h.Bans.GetActive(ctx, account.ID)
+	if err != nil {
+		h.internalError(ctx, w, "check account ban", err)
+		return
+	}
+	if ban != nil {
+		writeError(w, errorCodeLogin, h.banMessage(ctx, ban))
+		return
+	}
+
+	players, err := h.Players.GetByAccountID(ctx, account.ID)
+	if err != nil {
+		h.internalError(ctx, w, "get characters", err)
+		return
+	}
+
+	premium := account.Premium(now)
+	resp := loginResponse{
+		Session: session{
+			SessionKey:     sessionKey(account.Name, req.Password, req.Token, now),
+			IsPremium:      premium.Active || account.Type >= models.AccountTypeGamemaster,
+			PremiumUntil:   premium.EndsAt,
+			Status:         "active",
+			ShowRewardNews: true,
+		},
+		PlayData: playData{Worlds: []world{}, Characters: []character{}},
+	}
+
+	for _, cfg := range h.Worlds {
+		resp.PlayData.Worlds = append(resp.PlayData.Worlds, world{
+			ID:                         cfg.ID,
+			Name:                       cfg.Name,
+			ExternalAddress:            cfg.Host,
+			ExternalPort:               cfg.Port,
+			ExternalAddressProtected:   cfg.Host,
+			ExternalPortProtected:      cfg.Port,
+			ExternalAddressUnprotected: cfg.Host,
+			ExternalPortUnprotected:    cfg.Port,
+			Location:                   cfg.Location,
+			PvpType:                    cfg.PvpType,
+			CurrentTournamentPhase:     2,
+		})
+	}
+
+	worldID := 0
+	if len(h.Worlds) > 0 {
+		worldID = h.Worlds[0].ID
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	for _, p := range players {
+		if p.Deletion != 0 {
+			continue
+		}
+		resp.Session.LastLoginTime = max(resp.Session.LastLoginTime, p.LastLogin)
+		resp.PlayData.Characters = append(resp.PlayData.Characters, character{
+			WorldID:     worldID,
+			Name:        p.Name,
+			IsMale:      p.Sex == 1,
+			Level:       p.Level,
+			Vocation:    vocationName(p.Vocation),
+			OutfitID:    p.LookType,
+			HeadColor:   p.LookHead,
+			TorsoColor:  p.LookBody,
+			LegsColor:   p.LookLegs,
+			DetailColor: p.LookFeet,
+			AddonsFlags: p.LookAddons,
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *Handler) cacheInfo(ctx context.Context, w http.ResponseWriter) {
+	online, err := h.Players.CountOnline(ctx)
+	if err != nil {
+		h.internalError(ctx, w, "count online players", err)
+		return
+	}
+	writeJSON(w, cacheInfoResponse{PlayersOnline: online})
+}
+
+// banMessage words a ban like the game server's login does.
+func (h *Handler) banMessage(ctx context.Context, ban *models.AccountBan) string {
+	bannedBy := "a gamemaster"
+	if player, err := h.Players.GetByID(ctx, ban.BannedBy); err == nil {
+		bannedBy = player.Name
+	}
+
+	if ban.ExpiresAt == 0 {
+		return fmt.Sprintf("Your account has been permanently banned by %s.\n\nReason specified:\n%s", bannedBy, ban.Reason)
+	}
+	until := time.Unix(ban.ExpiresAt, 0).Format("02 Jan 2006 15:04")
+	return fmt.Sprintf("Your account has been banned until %s by %s.\n\nReason specified:\n%s", until, bannedBy, ban.Reason)
+}
+
+func (h *Handler) internalError(ctx context.Context, w http.ResponseWriter, action string, err error) {
+	slog.ErrorContext(ctx, "login.php request failed", "action", action, "error", err)
+	writeError(w, errorCodeLogin, "Internal error, please try again later.")
+}
+
+// sessionKey is what the client hands the game server when it picks a
+// character: account name, password, authenticator token and the 30 second
+// step the token was checked at, one per line.
+func sessionKey(accountName, password, token string, now time.Time) string {
+	return strings.Join([]string{accountName, password, token, strconv.FormatInt(now.Unix()/30, 10)}, "\n")
+}
+
+func vocationName(id int) string {
+	if name, ok := vocationNames[id]; ok {
+		return name
+	}
+	return "None"
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, errorResponse{ErrorCode: code, ErrorMessage: message})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write login.php response", "error", err)
+	}
+}
+
+// ParsePvpType maps a world type as written in TFS's config.lua to the
+// client's numbering.
+func ParsePvpType(value string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "pvp":
+		return PvpTypeOpen, nil
+	case "no-pvp", "nopvp":
+		return PvpTypeOptional, nil
+	case "pvp-enforced", "pvpenforced":
+		return PvpTypeHardcore, nil
+	}
+	return 0, fmt.Errorf("unknown world type %q", value)
+}
@@ -0,0 +1,203 @@
+package login
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	accountColumns = []string{"id", "name", "password", "secret", "type", "premium_ends_at", "email", "creation"}
+	playerColumns  = []string{"id", "name", "group_id", "account_id", "level", "vocation", "health", "healthmax",
+		"experience", "lookbody", "lookfeet", "lookhead", "looklegs", "looktype", "lookaddons",
+		"maglevel", "mana", "manamax", "soul", "town_id", "posx", "posy", "posz", "cap", "sex",
+		"lastlogin", "balance", "deletion"}
+	banColumns = []string{"account_id", "reason", "banned_at", "expires_at", "banned_by"}
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+func newTestHandler(t *testing.T) (*Handler, sqlmock.Sqlmock) {
+	db, mock, err := models.NewMockDB()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	h := NewHandler(
+		models.NewAccountRepository(db),
+		models.NewPlayerRepository(db),
+		models.NewAccountBanRepository(db),
+		[]World{{ID: 0, Name: "Forgotten", Host: "game.example.com", Port: 7172, Location: "EUR", PvpType: PvpTypeOptional}},
+	)
+	h.now = func() time.Time { return testNow }
+	return h, mock
+}
+
+func post(h http.Handler, body string) map[string]any {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login.php", strings.NewReader(body)))
+
+	var resp map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp
+}
+
+func expectAccount(mock sqlmock.Sqlmock, secret any) {
+	mock.ExpectQuery("FROM accounts WHERE name = \\?").
+		WithArgs("acc").
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, "acc", models.HashPassword("secret123"), secret, 1, testNow.Unix()+3600, "a@example.com", 0))
+}
+
+func TestHandler_Login(t *testing.T) {
+	h, mock := newTestHandler(t)
+
+	expectAccount(mock, nil)
+	mock.ExpectQuery("FROM account_bans").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(banColumns))
+	mock.ExpectQuery("FROM players").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(playerColumns).
+			AddRow(2, "Zed", 1, 1, 50, 8, 0, 0, 0, 10, 20, 30, 40, 131, 3, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 1699990000, 0, 0).
+			AddRow(3, "Gone", 1, 1, 8, 1, 0, 0, 0, 0, 0, 0, 0, 130, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 1699999999, 0, 1700100000).
+			AddRow(4, "Amy", 1, 1, 8, 2, 0, 0, 0, 0, 0, 0, 0, 136, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1699980000, 0, 0))
+
+	resp := post(h, `{"type":"login","accountname":"acc","password":"secret123","stayloggedin":true}`)
+
+	session := resp["session"].(map[string]any)
+	assert.Equal(t, "acc\nsecret123\n\n56666666", session["sessionkey"])
+	assert.Equal(t, true, session["ispremium"])
+	assert.Equal(t, float64(1699990000), session["lastlogintime"])
+
+	playdata := resp["playdata"].(map[string]any)
+	worlds := playdata["worlds"].([]any)
+	require.Len(t, worlds, 1)
+	world := worlds[0].(map[string]any)
+	assert.Equal(t, "game.example.com", world["externaladdress"])
+	assert.Equal(t, float64(7172), world["externalportprotected"])
+	assert.Equal(t, float64(PvpTypeOptional), world["pvptype"])
+
+	characters := playdata["characters"].([]any)
+	require.Len(t, characters, 2, "characters pending deletion are left out")
+	amy, zed := characters[0].(map[string]any), characters[1].(map[string]any)
+	assert.Equal(t, "Amy", amy["name"])
+	assert.Equal(t, false, amy["ismale"])
+	assert.Equal(t, "Druid", amy["vocation"])
+	assert.Equal(t, "Elite Knight", zed["vocation"])
+	assert.Equal(t, float64(131), zed["outfitid"])
+	assert.Equal(t, float64(30), zed["headcolor"])
+	assert.Equal(t, float64(10), zed["torsocolor"])
+	assert.Equal(t, float64(40), zed["legscolor"])
+	assert.Equal(t, float64(20), zed["detailcolor"])
+	assert.Equal(t, float64(3), zed["addonsflags"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_LoginErrors(t *testing.T) {
+	t.Run("WrongPassword", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		expectAccount(mock, nil)
+
+		resp := post(h, `{"type":"login","accountname":"acc","password":"wrong"}`)
+		assert.Equal(t, float64(errorCodeLogin), resp["errorCode"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TwoFactorRequired", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		expectAccount(mock, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+		resp := post(h, `{"type":"login","accountname":"acc","password":"secret123"}`)
+		assert.Equal(t, float64(errorCodeTwoFactor), resp["errorCode"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WrongToken", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		expectAccount(mock, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+		resp := post(h, `{"type":"login","accountname":"acc","password":"secret123","token":"000000"}`)
+		assert.Equal(t, float64(errorCodeTwoFactor), resp["errorCode"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Banned", func(t *testing.T) {
+		h, mock := newTestHandler(t)
+		expectAccount(mock, nil)
+		mock.ExpectQuery("FROM account_bans").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(banColumns).AddRow(1, "Botting", 1690000000, 0, 5))
+		mock.ExpectQuery("FROM players WHERE id = \\?").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(playerColumns).
+				AddRow(5, "GM Bob", 6, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 75, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0))
+
+		resp := post(h, `{"type":"login","accountname":"acc","password":"secret123"}`)
+		assert.Equal(t, float64(errorCodeLogin), resp["errorCode"])
+		assert.Equal(t, "Your account has been permanently banned by GM Bob.\n\nReason specified:\nBotting", resp["errorMessage"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		h, _ := newTestHandler(t)
+
+		resp := post(h, `{"type":"boostedcreature"}`)
+		assert.Equal(t, float64(errorCodeLogin), resp["errorCode"])
+	})
+}
+
+func TestHandler_CacheInfo(t *testing.T) {
+	h, mock := newTestHandler(t)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	resp := post(h, `{"type":"cacheinfo"}`)
+	assert.Equal(t, float64(42), resp["playersonline"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	h, mock := newTestHandler(t)
+	h.Limiter = ratelimit.New(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+		"login": {Requests: 1, Per: time.Minute},
+	})
+
+	expectAccount(mock, nil)
+	resp := post(h, `{"type":"login","accountname":"acc","password":"wrong"}`)
+	assert.Equal(t, "Account name or password is not correct.", resp["errorMessage"])
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login.php",
+		strings.NewReader(`{"type":"login","accountname":"acc","password":"secret123"}`)))
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), "Too many login attempts. Please try again in 60 seconds.")
+
+	// Other request types don't count as attempts.
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	resp = post(h, `{"type":"cacheinfo"}`)
+	assert.Equal(t, float64(1), resp["playersonline"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParsePvpType(t *testing.T) {
+	for value, want := range map[string]int{"pvp": PvpTypeOpen, "no-pvp": PvpTypeOptional, "PVP-ENFORCED": PvpTypeHardcore} {
+		got, err := ParsePvpType(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := ParsePvpType("anarchy")
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender()
+
+	require.NoError(t, sender.Send(context.Background(), Message{To: "a@example.com", Subject: "Hi", Body: "Hello"}))
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "a@example.com", messages[0].To)
+}
+
+func TestSMTPSender_Format(t *testing.T) {
+	sender := &SMTPSender{From: "noreply@example.com"}
+
+	data := string(sender.format(Message{To: "a@example.com", Subject: "Reset", Body: "line 1\nline 2"}))
+
+	assert.True(t, strings.HasPrefix(data, "From: noreply@example.com\r\nTo: a@example.com\r\nSubject: Reset\r\n"))
+	assert.True(t, strings.HasSuffix(data, "\r\n\r\nline 1\r\nline 2"))
+}
+
+func TestSMTPSender_FormatStripsHeaderBreaks(t *testing.T) {
+	sender := &SMTPSender{From: "noreply@example.com"}
+
+	data := string(sender.format(Message{To: "a@example.com\r\nBcc: b@example.com", Subject: "Reset"}))
+
+	assert.NotContains(t, data, "\r\nBcc:")
+}
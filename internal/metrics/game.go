@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	playersOnlineDesc = prometheus.NewDesc("tfs_players_online",
+		"Players logged in to the game server.", nil, nil)
+	marketOffersDesc = prometheus.NewDesc("tfs_market_offers",
+		"Open market offers, by side.", []string{"side"}, nil)
+)
+
+// Game reports gauges about the game world, read when scraped.
+type Game struct {
+	PlayersOnline func(ctx context.Context) (int, error)
+	MarketOffers  func(ctx context.Context) (*models.MarketOfferCounts, error)
+
+	// How long a scrape may spend reading them
+	Timeout time.Duration
+}
+
+var _ prometheus.Collector = (*Game)(nil)
+
+func (g *Game) Describe(ch chan<- *prometheus.Desc) {
+	ch <- playersOnlineDesc
+	ch <- marketOffersDesc
+}
+
+func (g *Game) Collect(ch chan<- prometheus.Metric) {
+	timeout := g.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if online, err := g.PlayersOnline(ctx); err != nil {
+		ch <- prometheus.NewInvalidMetric(playersOnlineDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(playersOnlineDesc, prometheus.GaugeValue, float64(online))
+	}
+
+	if offers, err := g.MarketOffers(ctx); err != nil {
+		ch <- prometheus.NewInvalidMetric(marketOffersDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(marketOffersDesc, prometheus.GaugeValue, float64(offers.Buy), "buy")
+		ch <- prometheus.MustNewConstMetric(marketOffersDesc, prometheus.GaugeValue, float64(offers.Sell), "sell")
+	}
+}
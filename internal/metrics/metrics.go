@@ -0,0 +1,195 @@
+// Package metrics exposes Prometheus metrics for GraphQL operations, the
+// database pool and the game itself.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const namespace = "tfs_api"
+
+// otherOperation labels named operations that weren't added with
+// AddOperations. Clients choose operation names, so labelling each one would
+// let them create any number of series.
+const otherOperation = "other"
+
+// Metrics collects GraphQL metrics as a gqlgen extension and serves
+// everything registered with it on Handler.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	fieldDuration *prometheus.HistogramVec
+	errors        *prometheus.CounterVec
+	subscriptions prometheus.Gauge
+
+	// Operation names counted under their own label
+	operations map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = (*Metrics)(nil)
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_requests_total",
+			Help:      "GraphQL operations handled, by operation name and type.",
+		}, []string{"operation", "type"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "graphql_operation_duration_seconds",
+			Help:      "Time to execute GraphQL operations, by operation name and type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "type"}),
+		fieldDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "graphql_field_duration_seconds",
+			Help:      "Time spent in GraphQL field resolvers, by object and field.",
+			Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"object", "field"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "graphql_errors_total",
+			Help:      "GraphQL errors returned, by error code.",
+		}, []string{"code"}),
+		subscriptions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "graphql_active_subscriptions",
+			Help:      "GraphQL subscriptions currently open over websockets.",
+		}),
+		operations: make(map[string]bool),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.duration, m.fieldDuration, m.errors, m.subscriptions,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// AddOperations lets operations with these names be counted under their own
+// name rather than as "other". It must be called before serving requests.
+func (m *Metrics) AddOperations(names ...string) {
+	for _, name := range names {
+		m.operations[name] = true
+	}
+}
+
+// Register adds more collectors, such as DBStats and Game.
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// DBStats collects connection pool statistics for db.
+func DBStats(db *sql.DB, name string) prometheus.Collector {
+	return collectors.NewDBStatsCollector(db, name)
+}
+
+func (m *Metrics) ExtensionName() string {
+	return "Metrics"
+}
+
+func (m *Metrics) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation tracks open subscriptions, which end when their
+// context is cancelled.
+func (m *Metrics) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		m.requests.WithLabelValues(m.operationName(oc), string(ast.Subscription)).Inc()
+		m.subscriptions.Inc()
+		go func() {
+			<-ctx.Done()
+			m.subscriptions.Dec()
+		}()
+	}
+	return next(ctx)
+}
+
+func (m *Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	// Requests that fail to parse or validate have no operation.
+	name, opType := "unknown", "unknown"
+	var start time.Time
+	if graphql.HasOperationContext(ctx) {
+		oc := graphql.GetOperationContext(ctx)
+		start = oc.Stats.OperationStart
+		if oc.Operation != nil {
+			name, opType = m.operationName(oc), string(oc.Operation.Operation)
+		}
+	}
+
+	// Subscriptions respond once per event and were counted when opened.
+	if opType != string(ast.Subscription) {
+		m.requests.WithLabelValues(name, opType).Inc()
+		if !start.IsZero() {
+			m.duration.WithLabelValues(name, opType).Observe(time.Since(start).Seconds())
+		}
+	}
+
+	if resp != nil {
+		for _, err := range resp.Errors {
+			code, _ := err.Extensions["code"].(string)
+			if code == "" {
+				code = "UNKNOWN"
+			}
+			m.errors.WithLabelValues(code).Inc()
+		}
+	}
+	return resp
+}
+
+// InterceptField times fields backed by a resolver; fields read straight
+// off a struct would only add noise.
+func (m *Metrics) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := time.Now()
+	res, err := next(ctx)
+	m.fieldDuration.WithLabelValues(fc.Object, fc.Field.Name).Observe(time.Since(start).Seconds())
+	return res, err
+}
+
+func (m *Metrics) operationName(oc *graphql.OperationContext) string {
+	name := oc.OperationName
+	if name == "" && oc.Operation != nil {
+		name = oc.Operation.Name
+	}
+	switch {
+	case name == "":
+		return "anonymous"
+	case m.operations[name]:
+		return name
+	default:
+		return otherOperation
+	}
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, m *Metrics) (http.Handler, sqlmock.Sqlmock) {
+	db, mock, err := models.NewMockDB()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(db)}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.Use(m)
+	return srv, mock
+}
+
+func query(h http.Handler, body string) {
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestMetrics_Operations(t *testing.T) {
+	m := New()
+	m.AddOperations("Info")
+	srv, mock := newTestServer(t, m)
+
+	mock.ExpectQuery("SELECT config, value FROM server_config").
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("db_version", "33"))
+	query(srv, `{"query":"query Info { serverInfo { dbVersion } }"}`)
+
+	mock.ExpectQuery("SELECT config, value FROM server_config").
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("db_version", "33"))
+	query(srv, `{"query":"query MadeUp123 { serverInfo { dbVersion } }"}`)
+
+	mock.ExpectQuery("FROM api_online_history").
+		WillReturnError(errors.New("boom"))
+	query(srv, `{"query":"{ onlineHistory(hours: 0) { sampledAt } }"}`)
+
+	query(srv, `{"query":"{ nope }"}`)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("Info", "query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("other", "query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("anonymous", "query")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("unknown", "unknown")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.errors.WithLabelValues("INVALID_HISTORY_RANGE")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.errors.WithLabelValues("GRAPHQL_VALIDATION_FAILED")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.fieldDuration), "serverInfo and onlineHistory were timed")
+	assert.Equal(t, 4, testutil.CollectAndCount(m.requests), "MadeUp123 got no series of its own")
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.Register(&Game{
+		PlayersOnline: func(ctx context.Context) (int, error) { return 12, nil },
+		MarketOffers: func(ctx context.Context) (*models.MarketOfferCounts, error) {
+			return &models.MarketOfferCounts{Buy: 3, Sell: 5}, nil
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, "tfs_players_online 12")
+	assert.Contains(t, body, `tfs_market_offers{side="buy"} 3`)
+	assert.Contains(t, body, `tfs_market_offers{side="sell"} 5`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestGame_CollectError(t *testing.T) {
+	game := &Game{
+		PlayersOnline: func(ctx context.Context) (int, error) { return 0, errors.New("down") },
+		MarketOffers: func(ctx context.Context) (*models.MarketOfferCounts, error) {
+			return &models.MarketOfferCounts{}, nil
+		},
+	}
+
+	_, err := testutil.CollectAndLint(game)
+	assert.Error(t, err, "a failed read is reported instead of a zero")
+}
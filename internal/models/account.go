@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
 )
@@ -54,11 +55,37 @@ func (r *AccountRepository) GetAll(ctx context.Context, limit int) ([]*Account,
 	return accounts, nil
 }
 
+// Search returns up to limit accounts whose name or email contains query,
+// accounts whose name starts with it first.
+func (r *AccountRepository) Search(ctx context.Context, query string, limit int) ([]*Account, error) {
+	q := strings.TrimSpace(query)
+	if q == "" || len([]rune(q)) > maxSearchQueryLength {
+		return nil, ErrInvalidSearch
+	}
+
+	var accounts []*Account
+	sqlQuery := `SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts
+	             WHERE name LIKE ? OR email LIKE ?
+	             ORDER BY name LIKE ? DESC, name
+	             LIMIT ?`
+	contains := "%" + escapeLike(q) + "%"
+
+	if err := r.db.SelectContext(ctx, &accounts, sqlQuery, contains, contains, escapeLike(q)+"%", limit); err != nil {
+		return nil, fmt.Errorf("failed to search accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
 func (r *AccountRepository) Create(ctx context.Context, input CreateAccountInput) (*Account, error) {
-	// Note: In production, hash the password with bcrypt
+	if err := validatePassword(input.Password); err != nil {
+		return nil, err
+	}
+
+	// TFS compares logins against the SHA-1 of the password
 	query := `INSERT INTO accounts (name, password, email, creation) VALUES (?, ?, ?, UNIX_TIMESTAMP())`
 
-	result, err := r.db.ExecContext(ctx, query, input.Name, input.Password, input.Email)
+	result, err := r.db.ExecContext(ctx, query, input.Name, HashPassword(input.Password), input.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create account: %w", err)
 	}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrAuditReasonRequired = errors.New("a reason is required for account changes")
+
+// AccountAuditAction is the kind of change recorded in an account's audit
+// log.
+type AccountAuditAction string
+
+const (
+	AccountAuditPremiumDays AccountAuditAction = "PREMIUM_DAYS"
+	AccountAuditAccountType AccountAuditAction = "ACCOUNT_TYPE"
+)
+
+// AccountAuditEntry records a staff change to an account, with the value
+// before and after it. Entries are only ever inserted.
+type AccountAuditEntry struct {
+	ID        int                `db:"id" json:"id"`
+	AccountID int                `db:"account_id" json:"accountId"`
+	Action    AccountAuditAction `db:"action" json:"action"`
+	OldValue  int64              `db:"old_value" json:"oldValue"`
+	NewValue  int64              `db:"new_value" json:"newValue"`
+	Actor     string             `db:"actor" json:"actor"`
+	Reason    string             `db:"reason" json:"reason"`
+	CreatedAt int64              `db:"created_at" json:"createdAt"`
+}
+
+// AuditInfo is who made an account change and why.
+type AuditInfo struct {
+	Actor  string
+	Reason string
+}
+
+func (a AuditInfo) validate() error {
+	if strings.TrimSpace(a.Reason) == "" {
+		return ErrAuditReasonRequired
+	}
+	return nil
+}
+
+// GetAuditLog returns an account's audit entries, newest first.
+func (r *AccountRepository) GetAuditLog(ctx context.Context, accountID int) ([]*AccountAuditEntry, error) {
+	var entries []*AccountAuditEntry
+	query := `SELECT id, account_id, action, old_value, new_value, actor, reason, created_at
+	          FROM api_account_audit WHERE account_id = ? ORDER BY id DESC`
+
+	if err := r.db.SelectContext(ctx, &entries, query, accountID); err != nil {
+		return nil, fmt.Errorf("failed to get account audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+func insertAudit(ctx context.Context, tx *sqlx.Tx, accountID int, action AccountAuditAction, oldValue, newValue int64, info AuditInfo) error {
+	query := `INSERT INTO api_account_audit (account_id, action, old_value, new_value, actor, reason, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?, UNIX_TIMESTAMP())`
+
+	if _, err := tx.ExecContext(ctx, query, accountID, action, oldValue, newValue, info.Actor, strings.TrimSpace(info.Reason)); err != nil {
+		return fmt.Errorf("failed to record account audit: %w", err)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
@@ -57,3 +59,20 @@ func (r *AccountBanRepository) Create(ctx context.Context, input BanAccountInput
 
 	return bans[0], nil
 }
+
+// GetActive returns the account's ban that is still in force, or nil. Bans
+// that never expire have expires_at 0.
+func (r *AccountBanRepository) GetActive(ctx context.Context, accountID int) (*AccountBan, error) {
+	var ban AccountBan
+	query := `SELECT account_id, reason, banned_at, expires_at, banned_by FROM account_bans
+	          WHERE account_id = ? AND (expires_at = 0 OR expires_at > UNIX_TIMESTAMP())`
+
+	if err := r.db.GetContext(ctx, &ban, query, accountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get account ban: %w", err)
+	}
+
+	return &ban, nil
+}
@@ -66,3 +66,30 @@ func TestAccountBanRepository_Create(t *testing.T) {
 	assert.Equal(t, int64(1234567900), ban.ExpiresAt)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestAccountBanRepository_GetActive(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAccountBanRepository(db)
+	columns := []string{"account_id", "reason", "banned_at", "expires_at", "banned_by"}
+
+	mock.ExpectQuery("FROM account_bans WHERE account_id = \\? AND \\(expires_at = 0 OR expires_at > UNIX_TIMESTAMP\\(\\)\\)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Botting", 1234567890, 0, 2))
+	mock.ExpectQuery("FROM account_bans WHERE account_id = \\?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	ban, err := repo.GetActive(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	assert.Equal(t, "Botting", ban.Reason)
+
+	ban, err = repo.GetActive(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Nil(t, ban)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
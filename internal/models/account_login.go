@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckLogin returns the account with the given name if password matches,
+// or ErrInvalidLogin.
+func (r *AccountRepository) CheckLogin(ctx context.Context, name, password string) (*Account, error) {
+	var account Account
+	query := `SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts WHERE name = ?`
+	if err := r.db.GetContext(ctx, &account, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidLogin
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if !checkPassword(account.Password, password) {
+		return nil, ErrInvalidLogin
+	}
+
+	return &account, nil
+}
+
+// CheckLoginByEmail is CheckLogin for clients that log in by email. TFS
+// doesn't keep emails unique, so the first account with that email and
+// password wins.
+func (r *AccountRepository) CheckLoginByEmail(ctx context.Context, email, password string) (*Account, error) {
+	var accounts []*Account
+	query := `SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts WHERE email = ? ORDER BY id`
+	if err := r.db.SelectContext(ctx, &accounts, query, email); err != nil {
+		return nil, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		if checkPassword(account.Password, password) {
+			return account, nil
+		}
+	}
+
+	return nil, ErrInvalidLogin
+}
+
+// HasTwoFactor reports whether the account has an authenticator secret set.
+func (a *Account) HasTwoFactor() bool {
+	return a.Secret != nil && *a.Secret != ""
+}
+
+// CheckTwoFactorToken reports whether token is the account's authenticator
+// code at now, allowing one 30 second step of clock drift either way like
+// the game server does.
+func (a *Account) CheckTwoFactorToken(token string, now time.Time) bool {
+	if !a.HasTwoFactor() {
+		return false
+	}
+
+	key, err := decodeSecret(*a.Secret)
+	if err != nil {
+		return false
+	}
+
+	ticks := uint64(now.Unix() / 30)
+	for _, t := range []uint64{ticks - 1, ticks, ticks + 1} {
+		if subtle.ConstantTimeCompare([]byte(totp(key, t)), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeSecret decodes a base32 secret as stored in accounts.secret.
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+}
+
+// totp returns the six digit RFC 6238 code for key at a 30 second step.
+func totp(key []byte, step uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000)
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRepository_CheckLogin(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+	hash := HashPassword("secret123")
+
+	mock.ExpectQuery("FROM accounts WHERE name = \\?").
+		WithArgs("acc").
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "acc", hash, nil, 1, 0, "a@example.com", 0))
+	mock.ExpectQuery("FROM accounts WHERE name = \\?").
+		WithArgs("acc").
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "acc", hash, nil, 1, 0, "a@example.com", 0))
+	mock.ExpectQuery("FROM accounts WHERE name = \\?").
+		WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows(accountColumns))
+
+	account, err := repo.CheckLogin(context.Background(), "acc", "secret123")
+	require.NoError(t, err)
+	assert.Equal(t, 1, account.ID)
+
+	_, err = repo.CheckLogin(context.Background(), "acc", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidLogin)
+
+	_, err = repo.CheckLogin(context.Background(), "nobody", "secret123")
+	assert.ErrorIs(t, err, ErrInvalidLogin)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepository_CheckLoginByEmail(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectQuery("FROM accounts WHERE email = \\? ORDER BY id").
+		WithArgs("a@example.com").
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, "first", HashPassword("other-pass"), nil, 1, 0, "a@example.com", 0).
+			AddRow(2, "second", HashPassword("secret123"), nil, 1, 0, "a@example.com", 0))
+
+	account, err := repo.CheckLoginByEmail(context.Background(), "a@example.com", "secret123")
+	require.NoError(t, err)
+	assert.Equal(t, "second", account.Name, "the account whose password matches is used")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccount_CheckTwoFactorToken(t *testing.T) {
+	// RFC 6238 test secret "12345678901234567890" in base32.
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	account := &Account{Secret: &secret}
+	now := time.Unix(1111111109, 0)
+
+	assert.True(t, account.HasTwoFactor())
+	assert.True(t, account.CheckTwoFactorToken("081804", now))
+	assert.True(t, account.CheckTwoFactorToken("081804", now.Add(30*time.Second)), "one step of drift is allowed")
+	assert.False(t, account.CheckTwoFactorToken("081804", now.Add(90*time.Second)))
+	assert.False(t, account.CheckTwoFactorToken("000000", now))
+
+	assert.False(t, (&Account{}).HasTwoFactor())
+	assert.False(t, (&Account{}).CheckTwoFactorToken("081804", now))
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 64
+)
+
+var (
+	ErrWrongPassword   = errors.New("current password is wrong")
+	ErrInvalidPassword = fmt.Errorf("password must be %d to %d characters long", minPasswordLength, maxPasswordLength)
+)
+
+// HashPassword hashes a password the way TFS 1.4 stores it in
+// accounts.password: the hex SHA-1 digest.
+func HashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkPassword reports whether password matches a stored hash.
+func checkPassword(hash, password string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.ToLower(hash)), []byte(HashPassword(password))) == 1
+}
+
+func validatePassword(password string) error {
+	if n := len([]rune(password)); n < minPasswordLength || n > maxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// ChangePassword replaces an account's password after checking the current
+// one, and ends every session of the account except the one with
+// sessionToken.
+func (r *AccountRepository) ChangePassword(ctx context.Context, accountID int, sessionToken, oldPassword, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var hash string
+		query := `SELECT password FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &hash, query, accountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("account %d not found", accountID)
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if !checkPassword(hash, oldPassword) {
+			return ErrWrongPassword
+		}
+
+		if err := setPassword(ctx, tx, accountID, newPassword); err != nil {
+			return err
+		}
+		return endOtherSessions(ctx, tx, accountID, sessionToken)
+	})
+}
+
+func setPassword(ctx context.Context, tx *sqlx.Tx, accountID int, password string) error {
+	query := `UPDATE accounts SET password = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, HashPassword(password), accountID); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashPassword(t *testing.T) {
+	assert.Equal(t, "cbfdac6008f9cab4083784cbd1874f76618d2a97", HashPassword("password123"))
+	assert.True(t, checkPassword("CBFDAC6008F9CAB4083784CBD1874F76618D2A97", "password123"))
+	assert.False(t, checkPassword(HashPassword("password123"), "password124"))
+}
+
+func TestAccountRepository_ChangePassword(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	t.Run("Changed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT password FROM accounts WHERE id = \\? FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(HashPassword("old-password")))
+		mock.ExpectExec("UPDATE accounts SET password = \\? WHERE id = \\?").
+			WithArgs(HashPassword("new-password"), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM api_account_sessions WHERE account_id = \\? AND token_hash <> \\?").
+			WithArgs(1, hashToken("current")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.ChangePassword(context.Background(), 1, "current", "old-password", "new-password")
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT password FROM accounts").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(HashPassword("old-password")))
+		mock.ExpectRollback()
+
+		err := repo.ChangePassword(context.Background(), 1, "current", "not-the-password", "new-password")
+
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TooShort", func(t *testing.T) {
+		err := repo.ChangePassword(context.Background(), 1, "current", "old-password", "short")
+		assert.ErrorIs(t, err, ErrInvalidPassword)
+	})
+}
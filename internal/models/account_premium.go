@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TFS account types, from AccountType_t.
+const (
+	AccountTypeNormal      = 1
+	AccountTypeTutor       = 2
+	AccountTypeSeniorTutor = 3
+	AccountTypeGamemaster  = 4
+	AccountTypeGod         = 5
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+var (
+	ErrInvalidPremiumDays = errors.New("premium days must not be zero")
+	ErrInvalidAccountType = errors.New("invalid account type")
+)
+
+// AccountPremium is an account's premium status at a point in time.
+type AccountPremium struct {
+	Active   bool  `json:"active"`
+	DaysLeft int   `json:"daysLeft"`
+	EndsAt   int64 `json:"endsAt"`
+}
+
+// Premium returns the account's premium status at now. Like the game
+// server, a partial day left counts as a whole day.
+func (a *Account) Premium(now time.Time) *AccountPremium {
+	premium := &AccountPremium{EndsAt: int64(a.PremiumEndsAt)}
+
+	remaining := premium.EndsAt - now.Unix()
+	if remaining > 0 {
+		premium.Active = true
+		premium.DaysLeft = int((remaining + secondsPerDay - 1) / secondsPerDay)
+	}
+
+	return premium
+}
+
+// premiumEndsAfter returns when premium ends after adding days to an account
+// whose premium ends at endsAt. Added days start from now if premium has
+// already run out; removed days never move the end past now.
+func premiumEndsAfter(endsAt, now int64, days int) int64 {
+	if days > 0 {
+		return max(endsAt, now) + int64(days)*secondsPerDay
+	}
+
+	newEnd := endsAt + int64(days)*secondsPerDay
+	if newEnd < now {
+		newEnd = min(endsAt, now)
+	}
+	return newEnd
+}
+
+// AddPremiumDays extends an account's premium time by days, or shortens it
+// when days is negative, and records the change in the audit log.
+func (r *AccountRepository) AddPremiumDays(ctx context.Context, accountID, days int, info AuditInfo) (*Account, error) {
+	if days == 0 {
+		return nil, ErrInvalidPremiumDays
+	}
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var current struct {
+			EndsAt int64 `db:"premium_ends_at"`
+			Now    int64 `db:"now"`
+		}
+		query := `SELECT premium_ends_at, UNIX_TIMESTAMP() AS now FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &current, query, accountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("account %d not found", accountID)
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+
+		endsAt := premiumEndsAfter(current.EndsAt, current.Now, days)
+
+		query = `UPDATE accounts SET premium_ends_at = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, endsAt, accountID); err != nil {
+			return fmt.Errorf("failed to update premium time: %w", err)
+		}
+
+		return insertAudit(ctx, tx, accountID, AccountAuditPremiumDays, current.EndsAt, endsAt, info)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, accountID)
+}
+
+// SetType changes an account's type and records the change in the audit
+// log.
+func (r *AccountRepository) SetType(ctx context.Context, accountID, accountType int, info AuditInfo) (*Account, error) {
+	if accountType < AccountTypeNormal || accountType > AccountTypeGod {
+		return nil, ErrInvalidAccountType
+	}
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var oldType int
+		query := `SELECT type FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &oldType, query, accountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("account %d not found", accountID)
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+
+		query = `UPDATE accounts SET type = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, accountType, accountID); err != nil {
+			return fmt.Errorf("failed to update account type: %w", err)
+		}
+
+		return insertAudit(ctx, tx, accountID, AccountAuditAccountType, int64(oldType), int64(accountType), info)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, accountID)
+}
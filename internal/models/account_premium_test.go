@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var accountColumns = []string{"id", "name", "password", "secret", "type", "premium_ends_at", "email", "creation"}
+
+func TestAccount_Premium(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+
+	tests := []struct {
+		name     string
+		endsAt   int
+		active   bool
+		daysLeft int
+	}{
+		{"Never", 0, false, 0},
+		{"Expired", 999_000, false, 0},
+		{"HoursLeft", 1_003_600, true, 1},
+		{"ExactDays", 1_000_000 + 2*secondsPerDay, true, 2},
+		{"PartialDay", 1_000_000 + 2*secondsPerDay + 1, true, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			premium := (&Account{PremiumEndsAt: tt.endsAt}).Premium(now)
+
+			assert.Equal(t, tt.active, premium.Active)
+			assert.Equal(t, tt.daysLeft, premium.DaysLeft)
+			assert.Equal(t, int64(tt.endsAt), premium.EndsAt)
+		})
+	}
+}
+
+func TestPremiumEndsAfter(t *testing.T) {
+	const now = 1_000_000
+
+	tests := []struct {
+		name   string
+		endsAt int64
+		days   int
+		want   int64
+	}{
+		{"ExtendsActive", now + secondsPerDay, 2, now + 3*secondsPerDay},
+		{"StartsFromNowWhenExpired", now - 5*secondsPerDay, 2, now + 2*secondsPerDay},
+		{"Shortens", now + 3*secondsPerDay, -1, now + 2*secondsPerDay},
+		{"ShortensToNow", now + secondsPerDay, -2, now},
+		{"KeepsExpiredEnd", now - secondsPerDay, -2, now - secondsPerDay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, premiumEndsAfter(tt.endsAt, now, tt.days))
+		})
+	}
+}
+
+func TestAccountRepository_AddPremiumDays(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	t.Run("Extends", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT premium_ends_at, UNIX_TIMESTAMP\\(\\) AS now FROM accounts WHERE id = \\? FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"premium_ends_at", "now"}).AddRow(0, 1_000_000))
+		mock.ExpectExec("UPDATE accounts SET premium_ends_at = \\? WHERE id = \\?").
+			WithArgs(int64(1_000_000+30*secondsPerDay), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO api_account_audit").
+			WithArgs(1, AccountAuditPremiumDays, int64(0), int64(1_000_000+30*secondsPerDay), "alice", "Store purchase #12").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts WHERE id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(accountColumns).
+				AddRow(1, "acc", "", nil, 1, 1_000_000+30*secondsPerDay, "a@example.com", 0))
+
+		account, err := repo.AddPremiumDays(context.Background(), 1, 30, AuditInfo{Actor: "alice", Reason: " Store purchase #12 "})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1_000_000+30*secondsPerDay, account.PremiumEndsAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ZeroDays", func(t *testing.T) {
+		_, err := repo.AddPremiumDays(context.Background(), 1, 0, AuditInfo{Actor: "alice", Reason: "test"})
+		assert.ErrorIs(t, err, ErrInvalidPremiumDays)
+	})
+
+	t.Run("MissingReason", func(t *testing.T) {
+		_, err := repo.AddPremiumDays(context.Background(), 1, 5, AuditInfo{Actor: "alice", Reason: "  "})
+		assert.ErrorIs(t, err, ErrAuditReasonRequired)
+	})
+}
+
+func TestAccountRepository_SetType(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	t.Run("Changed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT type FROM accounts WHERE id = \\? FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow(AccountTypeNormal))
+		mock.ExpectExec("UPDATE accounts SET type = \\? WHERE id = \\?").
+			WithArgs(AccountTypeTutor, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO api_account_audit").
+			WithArgs(1, AccountAuditAccountType, int64(AccountTypeNormal), int64(AccountTypeTutor), "alice", "Promoted to tutor").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "acc", "", nil, AccountTypeTutor, 0, "a@example.com", 0))
+
+		account, err := repo.SetType(context.Background(), 1, AccountTypeTutor, AuditInfo{Actor: "alice", Reason: "Promoted to tutor"})
+
+		require.NoError(t, err)
+		assert.Equal(t, AccountTypeTutor, account.Type)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidType", func(t *testing.T) {
+		_, err := repo.SetType(context.Background(), 1, 6, AuditInfo{Actor: "alice", Reason: "test"})
+		assert.ErrorIs(t, err, ErrInvalidAccountType)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT type FROM accounts").
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"type"}))
+		mock.ExpectRollback()
+
+		_, err := repo.SetType(context.Background(), 2, AccountTypeGod, AuditInfo{Actor: "alice", Reason: "test"})
+
+		assert.EqualError(t, err, "account 2 not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountRepository_GetAuditLog(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectQuery("SELECT id, account_id, action, old_value, new_value, actor, reason, created_at " +
+		"FROM api_account_audit WHERE account_id = \\? ORDER BY id DESC").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "action", "old_value", "new_value", "actor", "reason", "created_at"}).
+			AddRow(2, 1, "ACCOUNT_TYPE", 1, 2, "alice", "Promoted", 1_000_100).
+			AddRow(1, 1, "PREMIUM_DAYS", 0, 2_592_000, "bob", "Purchase", 1_000_000))
+
+	entries, err := repo.GetAuditLog(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, AccountAuditAccountType, entries[0].Action)
+	assert.Equal(t, "bob", entries[1].Actor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
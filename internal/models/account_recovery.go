@@ -0,0 +1,245 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrInvalidRecoveryKey = errors.New("account name or recovery key is not correct")
+	ErrTooManyAttempts    = errors.New("too many recovery attempts; try again later")
+	ErrInvalidEmail       = errors.New("invalid email address")
+)
+
+// Recovery keys are 120 random bits, shown as six groups of four base32
+// characters.
+const (
+	recoveryKeyBytes = 15
+	recoveryKeyGroup = 4
+)
+
+// RecoveryLimit caps failed recovery attempts within a window, from one
+// client address and against one account name. The account limit is kept
+// higher so a single address can't lock the owner out.
+type RecoveryLimit struct {
+	MaxAttempts        int
+	MaxAccountAttempts int
+	Window             time.Duration
+}
+
+func DefaultRecoveryLimit() RecoveryLimit {
+	return RecoveryLimit{MaxAttempts: 5, MaxAccountAttempts: 20, Window: time.Hour}
+}
+
+type RecoverAccountInput struct {
+	Name        string
+	RecoveryKey string
+	NewPassword string
+	NewEmail    string
+
+	// IP is the client address, used for rate limiting and the attempt log
+	IP string
+}
+
+type AccountRecoveryRepository struct {
+	db    *database.DB
+	limit RecoveryLimit
+}
+
+func NewAccountRecoveryRepository(db *database.DB) *AccountRecoveryRepository {
+	return &AccountRecoveryRepository{db: db, limit: DefaultRecoveryLimit()}
+}
+
+// SetLimit replaces the recovery rate limit.
+func (r *AccountRecoveryRepository) SetLimit(limit RecoveryLimit) {
+	r.limit = limit
+}
+
+// GenerateKey creates a new recovery key for an account after checking its
+// password, replacing any earlier key. The key is returned once; only its
+// hash is kept.
+func (r *AccountRecoveryRepository) GenerateKey(ctx context.Context, accountID int, password string) (string, error) {
+	b := make([]byte, recoveryKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery key: %w", err)
+	}
+	key := formatRecoveryKey(base32.StdEncoding.EncodeToString(b))
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var hash string
+		query := `SELECT password FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &hash, query, accountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("account %d not found", accountID)
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if !checkPassword(hash, password) {
+			return ErrWrongPassword
+		}
+
+		query = `INSERT INTO api_account_recovery_keys (account_id, key_hash, created_at)
+		         VALUES (?, ?, UNIX_TIMESTAMP())
+		         ON DUPLICATE KEY UPDATE key_hash = VALUES(key_hash), created_at = VALUES(created_at)`
+		if _, err := tx.ExecContext(ctx, query, accountID, hashRecoveryKey(key)); err != nil {
+			return fmt.Errorf("failed to save recovery key: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// HasKey reports whether an account has a recovery key.
+func (r *AccountRecoveryRepository) HasKey(ctx context.Context, accountID int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_account_recovery_keys WHERE account_id = ?`
+	if err := r.db.GetContext(ctx, &count, query, accountID); err != nil {
+		return false, fmt.Errorf("failed to get recovery key: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// Recover takes over an account with its recovery key: it sets a new
+// password and email, turns off two-factor authentication, ends all
+// sessions and uses up the key. Attempts that get past the rate limit are
+// recorded in api_account_recovery_attempts, and failures count towards it.
+func (r *AccountRecoveryRepository) Recover(ctx context.Context, input RecoverAccountInput) error {
+	if err := validatePassword(input.NewPassword); err != nil {
+		return err
+	}
+	if err := validateEmail(input.NewEmail); err != nil {
+		return err
+	}
+
+	invalid := false
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		// Locking the account queues up concurrent attempts on it, so each
+		// one counts the failures recorded before it
+		var stored struct {
+			AccountID int            `db:"account_id"`
+			KeyHash   sql.NullString `db:"key_hash"`
+		}
+		query := `SELECT a.id AS account_id, k.key_hash FROM accounts a
+		          LEFT JOIN api_account_recovery_keys k ON k.account_id = a.id
+		          WHERE a.name = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &stored, query, input.Name); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get recovery key: %w", err)
+		}
+
+		if err := r.checkAttempts(ctx, tx, input); err != nil {
+			return err
+		}
+
+		if !stored.KeyHash.Valid ||
+			subtle.ConstantTimeCompare([]byte(stored.KeyHash.String), []byte(hashRecoveryKey(input.RecoveryKey))) != 1 {
+			invalid = true
+			return logRecoveryAttempt(ctx, tx, input, false)
+		}
+		accountID := stored.AccountID
+
+		query = `UPDATE accounts SET password = ?, email = ?, secret = NULL WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, HashPassword(input.NewPassword), strings.TrimSpace(input.NewEmail), accountID); err != nil {
+			return fmt.Errorf("failed to recover account: %w", err)
+		}
+
+		query = `DELETE FROM api_account_recovery_keys WHERE account_id = ?`
+		if _, err := tx.ExecContext(ctx, query, accountID); err != nil {
+			return fmt.Errorf("failed to use recovery key: %w", err)
+		}
+
+		query = `UPDATE api_account_tokens SET used_at = UNIX_TIMESTAMP() WHERE account_id = ? AND used_at IS NULL`
+		if _, err := tx.ExecContext(ctx, query, accountID); err != nil {
+			return fmt.Errorf("failed to revoke account tokens: %w", err)
+		}
+
+		if err := endSessions(ctx, tx, accountID); err != nil {
+			return err
+		}
+		return logRecoveryAttempt(ctx, tx, input, true)
+	})
+	if err != nil {
+		return err
+	}
+	if invalid {
+		return ErrInvalidRecoveryKey
+	}
+
+	return nil
+}
+
+// checkAttempts returns ErrTooManyAttempts once the client address or the
+// account name has used up its failed attempts.
+func (r *AccountRecoveryRepository) checkAttempts(ctx context.Context, tx *sqlx.Tx, input RecoverAccountInput) error {
+	window := int64(r.limit.Window.Seconds())
+
+	var failures int
+	query := `SELECT COUNT(*) FROM api_account_recovery_attempts
+	          WHERE ip = ? AND success = 0 AND attempted_at > UNIX_TIMESTAMP() - ?`
+	if err := tx.GetContext(ctx, &failures, query, input.IP, window); err != nil {
+		return fmt.Errorf("failed to count recovery attempts: %w", err)
+	}
+	if failures >= r.limit.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+
+	query = `SELECT COUNT(*) FROM api_account_recovery_attempts
+	         WHERE account_name = ? AND success = 0 AND attempted_at > UNIX_TIMESTAMP() - ?`
+	if err := tx.GetContext(ctx, &failures, query, input.Name, window); err != nil {
+		return fmt.Errorf("failed to count recovery attempts: %w", err)
+	}
+	if failures >= r.limit.MaxAccountAttempts {
+		return ErrTooManyAttempts
+	}
+
+	return nil
+}
+
+func logRecoveryAttempt(ctx context.Context, tx *sqlx.Tx, input RecoverAccountInput, success bool) error {
+	query := `INSERT INTO api_account_recovery_attempts (account_name, ip, success, attempted_at)
+	          VALUES (?, ?, ?, UNIX_TIMESTAMP())`
+	if _, err := tx.ExecContext(ctx, query, input.Name, input.IP, success); err != nil {
+		return fmt.Errorf("failed to log recovery attempt: %w", err)
+	}
+	return nil
+}
+
+func formatRecoveryKey(encoded string) string {
+	var groups []string
+	for i := 0; i < len(encoded); i += recoveryKeyGroup {
+		groups = append(groups, encoded[i:min(i+recoveryKeyGroup, len(encoded))])
+	}
+	return strings.Join(groups, "-")
+}
+
+// hashRecoveryKey hashes a key ignoring case, dashes and spaces, so players
+// can type it however it was written down.
+func hashRecoveryKey(key string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(key))
+	return hashToken(normalized)
+}
+
+func validateEmail(email string) error {
+	email = strings.TrimSpace(email)
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 255 {
+		return ErrInvalidEmail
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capturedArg records the value a query was called with.
+type capturedArg struct {
+	value driver.Value
+}
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
+func TestAccountRecoveryRepository_GenerateKey(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRecoveryRepository(db)
+
+	t.Run("Generated", func(t *testing.T) {
+		stored := &capturedArg{}
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT password FROM accounts WHERE id = \\? FOR UPDATE").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(HashPassword("password123")))
+		mock.ExpectExec("INSERT INTO api_account_recovery_keys \\(account_id, key_hash, created_at\\)").
+			WithArgs(1, stored).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		key, err := repo.GenerateKey(context.Background(), 1, "password123")
+
+		require.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`^[A-Z2-7]{4}(-[A-Z2-7]{4}){5}$`), key)
+		assert.Equal(t, hashRecoveryKey(key), stored.value)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT password FROM accounts").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"password"}).AddRow(HashPassword("password123")))
+		mock.ExpectRollback()
+
+		_, err := repo.GenerateKey(context.Background(), 1, "wrong")
+
+		assert.ErrorIs(t, err, ErrWrongPassword)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestHashRecoveryKey_IgnoresFormatting(t *testing.T) {
+	assert.Equal(t, hashRecoveryKey("ABCD-EFGH-IJKL"), hashRecoveryKey("abcd efgh ijkl"))
+}
+
+func TestAccountRecoveryRepository_Recover(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRecoveryRepository(db)
+	repo.SetLimit(RecoveryLimit{MaxAttempts: 3, MaxAccountAttempts: 10, Window: time.Hour})
+
+	input := RecoverAccountInput{
+		Name:        "player",
+		RecoveryKey: "abcd-efgh",
+		NewPassword: "new-password",
+		NewEmail:    "new@example.com",
+		IP:          "203.0.113.7",
+	}
+
+	expectKey := func(rows *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT a.id AS account_id, k.key_hash FROM accounts a " +
+			"LEFT JOIN api_account_recovery_keys k ON k.account_id = a.id WHERE a.name = \\? FOR UPDATE").
+			WithArgs("player").
+			WillReturnRows(rows)
+	}
+	keyRows := func(key string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"account_id", "key_hash"}).AddRow(1, hashRecoveryKey(key))
+	}
+	expectAttempts := func(ipFailures, nameFailures int) {
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_account_recovery_attempts "+
+			"WHERE ip = \\? AND success = 0 AND attempted_at > UNIX_TIMESTAMP\\(\\) - \\?").
+			WithArgs("203.0.113.7", int64(3600)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(ipFailures))
+		if ipFailures >= 3 {
+			return
+		}
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_account_recovery_attempts "+
+			"WHERE account_name = \\? AND success = 0 AND attempted_at > UNIX_TIMESTAMP\\(\\) - \\?").
+			WithArgs("player", int64(3600)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(nameFailures))
+	}
+	expectLog := func(success bool) {
+		mock.ExpectExec("INSERT INTO api_account_recovery_attempts \\(account_name, ip, success, attempted_at\\)").
+			WithArgs("player", "203.0.113.7", success).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	t.Run("Recovered", func(t *testing.T) {
+		expectKey(keyRows("ABCDEFGH"))
+		expectAttempts(2, 9)
+		mock.ExpectExec("UPDATE accounts SET password = \\?, email = \\?, secret = NULL WHERE id = \\?").
+			WithArgs(HashPassword("new-password"), "new@example.com", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM api_account_recovery_keys WHERE account_id = \\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE api_account_tokens SET used_at").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM api_account_sessions WHERE account_id = \\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectLog(true)
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Recover(context.Background(), input))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		expectKey(keyRows("ZZZZ-ZZZZ"))
+		expectAttempts(0, 0)
+		expectLog(false)
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), input), ErrInvalidRecoveryKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoKey", func(t *testing.T) {
+		expectKey(sqlmock.NewRows([]string{"account_id", "key_hash"}).AddRow(1, nil))
+		expectAttempts(0, 0)
+		expectLog(false)
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), input), ErrInvalidRecoveryKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoAccount", func(t *testing.T) {
+		expectKey(sqlmock.NewRows([]string{"account_id", "key_hash"}))
+		expectAttempts(0, 0)
+		expectLog(false)
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), input), ErrInvalidRecoveryKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AddressRateLimited", func(t *testing.T) {
+		expectKey(keyRows("ABCDEFGH"))
+		expectAttempts(3, 0)
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), input), ErrTooManyAttempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AccountRateLimited", func(t *testing.T) {
+		expectKey(keyRows("ABCDEFGH"))
+		expectAttempts(0, 10)
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), input), ErrTooManyAttempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidEmail", func(t *testing.T) {
+		bad := input
+		bad.NewEmail = "Player <new@example.com>"
+
+		assert.ErrorIs(t, repo.Recover(context.Background(), bad), ErrInvalidEmail)
+
+		bad.NewEmail = strings.Repeat("a", 250) + "@example.com"
+		assert.ErrorIs(t, repo.Recover(context.Background(), bad), ErrInvalidEmail)
+	})
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrInvalidLogin = errors.New("account name or password is not correct")
+
+// AccountSession is a bearer token an account logged in with. Only a hash
+// of the token is stored.
+type AccountSession struct {
+	Token     string `json:"token"`
+	AccountID int    `json:"accountId"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+type AccountSessionRepository struct {
+	db  *database.DB
+	ttl time.Duration
+}
+
+func NewAccountSessionRepository(db *database.DB) *AccountSessionRepository {
+	return &AccountSessionRepository{db: db, ttl: 24 * time.Hour}
+}
+
+// SetTTL sets how long new sessions last.
+func (r *AccountSessionRepository) SetTTL(ttl time.Duration) {
+	r.ttl = ttl
+}
+
+// Login checks an account's name and password and starts a session.
+func (r *AccountSessionRepository) Login(ctx context.Context, name, password string) (*AccountSession, error) {
+	var account struct {
+		ID       int    `db:"id"`
+		Password string `db:"password"`
+	}
+	query := `SELECT id, password FROM accounts WHERE name = ?`
+	if err := r.db.GetContext(ctx, &account, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidLogin
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	if !checkPassword(account.Password, password) {
+		return nil, ErrInvalidLogin
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &AccountSession{
+		Token:     token,
+		AccountID: account.ID,
+		ExpiresAt: time.Now().Add(r.ttl).Unix(),
+	}
+	query = `INSERT INTO api_account_sessions (token_hash, account_id, created_at, expires_at)
+	         VALUES (?, ?, UNIX_TIMESTAMP(), ?)`
+	if _, err := r.db.ExecContext(ctx, query, hash, session.AccountID, session.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Authenticate returns the account a session token belongs to, or 0 when the
+// token is unknown or expired.
+func (r *AccountSessionRepository) Authenticate(ctx context.Context, token string) (int, error) {
+	var accountID int
+	query := `SELECT account_id FROM api_account_sessions WHERE token_hash = ? AND expires_at > UNIX_TIMESTAMP()`
+	if err := r.db.GetContext(ctx, &accountID, query, hashToken(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return accountID, nil
+}
+
+// Logout ends a session and reports whether it existed.
+func (r *AccountSessionRepository) Logout(ctx context.Context, token string) (bool, error) {
+	query := `DELETE FROM api_account_sessions WHERE token_hash = ?`
+	result, err := r.db.ExecContext(ctx, query, hashToken(token))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteExpired removes expired sessions and account tokens, returning how
+// many rows were removed.
+func (r *AccountSessionRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+	for _, query := range []string{
+		`DELETE FROM api_account_sessions WHERE expires_at <= UNIX_TIMESTAMP()`,
+		`DELETE FROM api_account_tokens WHERE expires_at <= UNIX_TIMESTAMP()`,
+	} {
+		result, err := r.db.ExecContext(ctx, query)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete expired sessions: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		deleted += affected
+	}
+
+	return deleted, nil
+}
+
+func endSessions(ctx context.Context, tx *sqlx.Tx, accountID int) error {
+	query := `DELETE FROM api_account_sessions WHERE account_id = ?`
+	if _, err := tx.ExecContext(ctx, query, accountID); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
+
+// endOtherSessions ends every session of an account except the one with
+// token.
+func endOtherSessions(ctx context.Context, tx *sqlx.Tx, accountID int, token string) error {
+	query := `DELETE FROM api_account_sessions WHERE account_id = ? AND token_hash <> ?`
+	if _, err := tx.ExecContext(ctx, query, accountID, hashToken(token)); err != nil {
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+	return nil
+}
+
+// newToken returns a random token and the hash stored in its place.
+func newToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountSessionRepository_Login(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountSessionRepository(db)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, password FROM accounts WHERE name = \\?").
+			WithArgs("player").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, HashPassword("password123")))
+		mock.ExpectExec("INSERT INTO api_account_sessions \\(token_hash, account_id, created_at, expires_at\\)").
+			WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		session, err := repo.Login(context.Background(), "player", "password123")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, session.AccountID)
+		assert.Len(t, session.Token, 64)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, password FROM accounts").
+			WithArgs("player").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, HashPassword("password123")))
+
+		_, err := repo.Login(context.Background(), "player", "wrong")
+
+		assert.ErrorIs(t, err, ErrInvalidLogin)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UnknownAccount", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, password FROM accounts").
+			WithArgs("nobody").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "password"}))
+
+		_, err := repo.Login(context.Background(), "nobody", "password123")
+
+		assert.ErrorIs(t, err, ErrInvalidLogin)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountSessionRepository_Authenticate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountSessionRepository(db)
+
+	mock.ExpectQuery("SELECT account_id FROM api_account_sessions WHERE token_hash = \\? AND expires_at > UNIX_TIMESTAMP\\(\\)").
+		WithArgs(hashToken("token")).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow(3))
+	mock.ExpectQuery("SELECT account_id FROM api_account_sessions").
+		WithArgs(hashToken("expired")).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}))
+
+	accountID, err := repo.Authenticate(context.Background(), "token")
+	require.NoError(t, err)
+	assert.Equal(t, 3, accountID)
+
+	accountID, err = repo.Authenticate(context.Background(), "expired")
+	require.NoError(t, err)
+	assert.Zero(t, accountID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountSessionRepository_Logout(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountSessionRepository(db)
+
+	mock.ExpectExec("DELETE FROM api_account_sessions WHERE token_hash = \\?").
+		WithArgs(hashToken("token")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	loggedOut, err := repo.Logout(context.Background(), "token")
+
+	require.NoError(t, err)
+	assert.True(t, loggedOut)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountSessionRepository_DeleteExpired_KeepsEmailVerification(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	sessions := NewAccountSessionRepository(db)
+	tokens := NewAccountTokenRepository(db)
+
+	mock.ExpectExec("^DELETE FROM api_account_sessions WHERE expires_at <= UNIX_TIMESTAMP\\(\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("^DELETE FROM api_account_tokens WHERE expires_at <= UNIX_TIMESTAMP\\(\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_account_verified_emails").
+		WithArgs(1, "player@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	deleted, err := sessions.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+
+	verified, err := tokens.EmailVerified(context.Background(), &Account{ID: 1, Email: "player@example.com"})
+
+	require.NoError(t, err)
+	assert.True(t, verified)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 type AccountStorage struct {
@@ -13,25 +14,23 @@ type AccountStorage struct {
 	Value     int `db:"value" json:"value"`
 }
 
-type VipEntry struct {
-	AccountID   int    `db:"account_id" json:"accountId"`
-	PlayerID    int    `db:"player_id" json:"playerId"`
-	Description string `db:"description" json:"description"`
-	Icon        int    `db:"icon" json:"icon"`
-	Notify      bool   `db:"notify" json:"notify"`
-}
-
 type AccountStorageRepository struct {
-	db *database.DB
+	db        *database.DB
+	vipLimits VipLimits
 }
 
 func NewAccountStorageRepository(db *database.DB) *AccountStorageRepository {
-	return &AccountStorageRepository{db: db}
+	return &AccountStorageRepository{db: db, vipLimits: DefaultVipLimits()}
+}
+
+// SetVipLimits replaces the VIP list sizes enforced by AddVipEntry.
+func (r *AccountStorageRepository) SetVipLimits(limits VipLimits) {
+	r.vipLimits = limits
 }
 
 func (r *AccountStorageRepository) GetByAccountID(ctx context.Context, accountID int) ([]*AccountStorage, error) {
 	var storage []*AccountStorage
-	query := `SELECT account_id, key, value FROM account_storage WHERE account_id = ?`
+	query := "SELECT account_id, `key`, value FROM account_storage WHERE account_id = ? ORDER BY `key`"
 
 	if err := r.db.SelectContext(ctx, &storage, query, accountID); err != nil {
 		return nil, fmt.Errorf("failed to get account storage: %w", err)
@@ -40,14 +39,56 @@ func (r *AccountStorageRepository) GetByAccountID(ctx context.Context, accountID
 	return storage, nil
 }
 
-func (r *AccountStorageRepository) GetVipList(ctx context.Context, accountID int) ([]*VipEntry, error) {
-	var vipList []*VipEntry
-	query := `SELECT account_id, player_id, description, icon, notify
-	          FROM account_viplist WHERE account_id = ?`
+// SetStorage writes an account storage value, creating the key if needed.
+// Characters of the account must be offline, since the game server keeps
+// account storage in memory while they play.
+func (r *AccountStorageRepository) SetStorage(ctx context.Context, accountID, key, value int) (*AccountStorage, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardAccountOffline(ctx, tx, accountID); err != nil {
+			return err
+		}
+
+		query := "INSERT INTO account_storage (account_id, `key`, value) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value = VALUES(value)"
+		if _, err := tx.ExecContext(ctx, query, accountID, key, value); err != nil {
+			return fmt.Errorf("failed to set account storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountStorage{AccountID: accountID, Key: key, Value: value}, nil
+}
+
+// DeleteStorage removes an account storage key and reports whether it
+// existed.
+func (r *AccountStorageRepository) DeleteStorage(ctx context.Context, accountID, key int) (bool, error) {
+	var affected int64
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardAccountOffline(ctx, tx, accountID); err != nil {
+			return err
+		}
+
+		query := "DELETE FROM account_storage WHERE account_id = ? AND `key` = ?"
+		result, err := tx.ExecContext(ctx, query, accountID, key)
+		if err != nil {
+			return fmt.Errorf("failed to delete account storage: %w", err)
+		}
+
+		affected, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
 
-	if err := r.db.SelectContext(ctx, &vipList, query, accountID); err != nil {
-		return nil, fmt.Errorf("failed to get vip list: %w", err)
+		return nil
+	})
+	if err != nil {
+		return false, err
 	}
 
-	return vipList, nil
+	return affected > 0, nil
 }
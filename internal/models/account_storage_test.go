@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectAccountOnlineCheck(mock sqlmock.Sqlmock, accountID int, onlinePlayerID int) {
+	rows := sqlmock.NewRows([]string{"player_id"})
+	if onlinePlayerID != 0 {
+		rows.AddRow(onlinePlayerID)
+	}
+	mock.ExpectQuery("SELECT o.player_id FROM players_online o INNER JOIN players p ON p.id = o.player_id " +
+		"WHERE p.account_id = \\? LIMIT 1$").
+		WithArgs(accountID).
+		WillReturnRows(rows)
+}
+
+func TestAccountStorageRepository_GetByAccountID(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	mock.ExpectQuery("SELECT account_id, `key`, value FROM account_storage WHERE account_id = \\? ORDER BY `key`").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "key", "value"}).AddRow(1, 100, 5))
+
+	storage, err := repo.GetByAccountID(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, storage, 1)
+	assert.Equal(t, 100, storage[0].Key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountStorageRepository_SetStorage(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	t.Run("Offline", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectAccountOnlineCheck(mock, 1, 0)
+		mock.ExpectExec("INSERT INTO account_storage \\(account_id, `key`, value\\) VALUES \\(\\?, \\?, \\?\\) "+
+			"ON DUPLICATE KEY UPDATE value = VALUES\\(value\\)").
+			WithArgs(1, 100, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		storage, err := repo.SetStorage(context.Background(), 1, 100, 5)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, storage.Value)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CharacterOnline", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectAccountOnlineCheck(mock, 1, 7)
+		mock.ExpectRollback()
+
+		storage, err := repo.SetStorage(context.Background(), 1, 100, 5)
+
+		var onlineErr *PlayerOnlineError
+		require.ErrorAs(t, err, &onlineErr)
+		assert.Equal(t, 7, onlineErr.PlayerID)
+		assert.Nil(t, storage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StaffOverride", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO account_storage").
+			WithArgs(1, 100, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.SetStorage(WithOnlineOverride(context.Background()), 1, 100, 5)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountStorageRepository_DeleteStorage(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	mock.ExpectBegin()
+	expectAccountOnlineCheck(mock, 1, 0)
+	mock.ExpectExec("DELETE FROM account_storage WHERE account_id = \\? AND `key` = \\?").
+		WithArgs(1, 100).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteStorage(context.Background(), 1, 100)
+
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -107,7 +107,7 @@ func TestAccountRepository_Create(t *testing.T) {
 	}
 
 	mock.ExpectExec("INSERT INTO accounts").
-		WithArgs(input.Name, input.Password, input.Email).
+		WithArgs(input.Name, "cbfdac6008f9cab4083784cbd1874f76618d2a97", input.Email).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Mock the GetByID call that happens after insert
@@ -127,6 +127,23 @@ func TestAccountRepository_Create(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAccountRepository_Create_InvalidPassword(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	account, err := repo.Create(context.Background(), CreateAccountInput{
+		Name:     "newuser",
+		Password: "short",
+		Email:    "newuser@example.com",
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	assert.Nil(t, account)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAccountRepository_Create_DuplicateName(t *testing.T) {
 	db, mock := setupMockDB(t)
 	defer db.Close()
@@ -140,7 +157,7 @@ func TestAccountRepository_Create_DuplicateName(t *testing.T) {
 	}
 
 	mock.ExpectExec("INSERT INTO accounts").
-		WithArgs(input.Name, input.Password, input.Email).
+		WithArgs(input.Name, HashPassword(input.Password), input.Email).
 		WillReturnError(sql.ErrConnDone) // Simulate duplicate key error
 
 	account, err := repo.Create(context.Background(), input)
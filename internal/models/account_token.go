@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/mail"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrInvalidToken = errors.New("token is invalid or has expired")
+	ErrMailDisabled = errors.New("account emails are not configured")
+)
+
+type tokenPurpose string
+
+const (
+	tokenPasswordReset tokenPurpose = "PASSWORD_RESET"
+	tokenEmailVerify   tokenPurpose = "EMAIL_VERIFY"
+)
+
+// AccountMail configures the emails sent for password resets and email
+// verification. The URLs may contain {token}, which is replaced with the
+// token; without a URL the bare token is sent.
+type AccountMail struct {
+	ResetURL  string
+	VerifyURL string
+	ResetTTL  time.Duration
+	VerifyTTL time.Duration
+}
+
+func DefaultAccountMail() AccountMail {
+	return AccountMail{
+		ResetTTL:  time.Hour,
+		VerifyTTL: 48 * time.Hour,
+	}
+}
+
+func (m AccountMail) link(url, token string) string {
+	if url == "" {
+		return token
+	}
+	return strings.ReplaceAll(url, "{token}", token)
+}
+
+// AccountTokenRepository issues and redeems single-use tokens sent by email.
+type AccountTokenRepository struct {
+	db     *database.DB
+	sender mail.Sender
+	mail   AccountMail
+}
+
+func NewAccountTokenRepository(db *database.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db, mail: DefaultAccountMail()}
+}
+
+// SetMailer sets how account emails are sent. Until it is called, requests
+// that need to send mail fail with ErrMailDisabled.
+func (r *AccountTokenRepository) SetMailer(sender mail.Sender, accountMail AccountMail) {
+	r.sender = sender
+	r.mail = accountMail
+}
+
+// RequestPasswordReset mails a reset token to every account registered with
+// email. It succeeds whether or not such an account exists, so callers
+// can't use it to find out which addresses are registered.
+func (r *AccountTokenRepository) RequestPasswordReset(ctx context.Context, email string) error {
+	if r.sender == nil {
+		return ErrMailDisabled
+	}
+
+	var accounts []*Account
+	query := `SELECT id, name, email FROM accounts WHERE email = ?`
+	if err := r.db.SelectContext(ctx, &accounts, query, strings.TrimSpace(email)); err != nil {
+		return fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		token, err := r.issue(ctx, account, tokenPasswordReset, r.mail.ResetTTL)
+		if err != nil {
+			return err
+		}
+
+		err = r.sender.Send(ctx, mail.Message{
+			To:      account.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("A password reset was requested for account %s.\n\n"+
+				"Use this to choose a new password within %s:\n%s\n\n"+
+				"If you didn't ask for this, you can ignore this email.",
+				account.Name, r.mail.ResetTTL, r.mail.link(r.mail.ResetURL, token)),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and ends the
+// account's sessions.
+func (r *AccountTokenRepository) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		accountID, _, err := redeemToken(ctx, tx, token, tokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := setPassword(ctx, tx, accountID, newPassword); err != nil {
+			return err
+		}
+
+		// Older reset links stop working once one has been used
+		query := `UPDATE api_account_tokens SET used_at = UNIX_TIMESTAMP()
+		          WHERE account_id = ? AND purpose = ? AND used_at IS NULL`
+		if _, err := tx.ExecContext(ctx, query, accountID, tokenPasswordReset); err != nil {
+			return fmt.Errorf("failed to revoke reset tokens: %w", err)
+		}
+
+		return endSessions(ctx, tx, accountID)
+	})
+}
+
+// SendVerification mails a token confirming the account's current email.
+func (r *AccountTokenRepository) SendVerification(ctx context.Context, account *Account) error {
+	if r.sender == nil {
+		return ErrMailDisabled
+	}
+
+	token, err := r.issue(ctx, account, tokenEmailVerify, r.mail.VerifyTTL)
+	if err != nil {
+		return err
+	}
+
+	return r.sender.Send(ctx, mail.Message{
+		To:      account.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Please confirm the email address of account %s within %s:\n%s",
+			account.Name, r.mail.VerifyTTL, r.mail.link(r.mail.VerifyURL, token)),
+	})
+}
+
+// VerifyEmail redeems a verification token. It fails if the account's email
+// has changed since the token was sent.
+func (r *AccountTokenRepository) VerifyEmail(ctx context.Context, token string) error {
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		accountID, email, err := redeemToken(ctx, tx, token, tokenEmailVerify)
+		if err != nil {
+			return err
+		}
+
+		var current string
+		query := `SELECT email FROM accounts WHERE id = ?`
+		if err := tx.GetContext(ctx, &current, query, accountID); err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if current != email {
+			return ErrInvalidToken
+		}
+
+		// Used tokens are purged once they expire, so the verified address
+		// is kept separately.
+		query = `INSERT INTO api_account_verified_emails (account_id, email, verified_at)
+		         VALUES (?, ?, UNIX_TIMESTAMP())
+		         ON DUPLICATE KEY UPDATE email = VALUES(email), verified_at = VALUES(verified_at)`
+		if _, err := tx.ExecContext(ctx, query, accountID, email); err != nil {
+			return fmt.Errorf("failed to save email verification: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// EmailVerified reports whether the account's current email was confirmed.
+func (r *AccountTokenRepository) EmailVerified(ctx context.Context, account *Account) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM api_account_verified_emails WHERE account_id = ? AND email = ?`
+	if err := r.db.GetContext(ctx, &count, query, account.ID, account.Email); err != nil {
+		return false, fmt.Errorf("failed to get email verification: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *AccountTokenRepository) issue(ctx context.Context, account *Account, purpose tokenPurpose, ttl time.Duration) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	query := `INSERT INTO api_account_tokens (token_hash, account_id, purpose, email, created_at, expires_at)
+	          VALUES (?, ?, ?, ?, UNIX_TIMESTAMP(), UNIX_TIMESTAMP() + ?)`
+	if _, err := r.db.ExecContext(ctx, query, hash, account.ID, purpose, account.Email, int64(ttl.Seconds())); err != nil {
+		return "", fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return token, nil
+}
+
+// redeemToken marks an unused, unexpired token as used and returns the
+// account and email it was issued for.
+func redeemToken(ctx context.Context, tx *sqlx.Tx, token string, purpose tokenPurpose) (int, string, error) {
+	var issued struct {
+		AccountID int    `db:"account_id"`
+		Email     string `db:"email"`
+	}
+	hash := hashToken(token)
+
+	query := `SELECT account_id, email FROM api_account_tokens
+	          WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > UNIX_TIMESTAMP()
+	          FOR UPDATE`
+	if err := tx.GetContext(ctx, &issued, query, hash, purpose); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", ErrInvalidToken
+		}
+		return 0, "", fmt.Errorf("failed to get token: %w", err)
+	}
+
+	query = `UPDATE api_account_tokens SET used_at = UNIX_TIMESTAMP() WHERE token_hash = ?`
+	if _, err := tx.ExecContext(ctx, query, hash); err != nil {
+		return 0, "", fmt.Errorf("failed to use token: %w", err)
+	}
+
+	return issued.AccountID, issued.Email, nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/mail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAccountMail() AccountMail {
+	return AccountMail{
+		ResetURL:  "https://example.com/reset?token={token}",
+		VerifyURL: "https://example.com/verify?token={token}",
+		ResetTTL:  time.Hour,
+		VerifyTTL: 48 * time.Hour,
+	}
+}
+
+func TestAccountTokenRepository_RequestPasswordReset(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	sender := mail.NewMemorySender()
+	repo := NewAccountTokenRepository(db)
+	repo.SetMailer(sender, testAccountMail())
+
+	mock.ExpectQuery("SELECT id, name, email FROM accounts WHERE email = \\?").
+		WithArgs("player@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "player", "player@example.com"))
+	mock.ExpectExec("INSERT INTO api_account_tokens \\(token_hash, account_id, purpose, email, created_at, expires_at\\)").
+		WithArgs(sqlmock.AnyArg(), 1, tokenPasswordReset, "player@example.com", int64(3600)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.RequestPasswordReset(context.Background(), " player@example.com ")
+
+	require.NoError(t, err)
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "player@example.com", messages[0].To)
+	assert.Contains(t, messages[0].Body, "account player")
+
+	_, token, found := strings.Cut(messages[0].Body, "https://example.com/reset?token=")
+	require.True(t, found)
+	assert.Len(t, strings.Fields(token)[0], 64)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountTokenRepository_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	sender := mail.NewMemorySender()
+	repo := NewAccountTokenRepository(db)
+	repo.SetMailer(sender, testAccountMail())
+
+	mock.ExpectQuery("SELECT id, name, email FROM accounts").
+		WithArgs("nobody@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}))
+
+	err := repo.RequestPasswordReset(context.Background(), "nobody@example.com")
+
+	require.NoError(t, err)
+	assert.Empty(t, sender.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountTokenRepository_MailDisabled(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountTokenRepository(db)
+
+	assert.ErrorIs(t, repo.RequestPasswordReset(context.Background(), "player@example.com"), ErrMailDisabled)
+	assert.ErrorIs(t, repo.SendVerification(context.Background(), &Account{ID: 1}), ErrMailDisabled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountTokenRepository_ResetPassword(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountTokenRepository(db)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT account_id, email FROM api_account_tokens WHERE token_hash = \\? AND purpose = \\? "+
+			"AND used_at IS NULL AND expires_at > UNIX_TIMESTAMP\\(\\) FOR UPDATE").
+			WithArgs(hashToken("reset-token"), tokenPasswordReset).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "email"}).AddRow(1, "player@example.com"))
+		mock.ExpectExec("UPDATE api_account_tokens SET used_at = UNIX_TIMESTAMP\\(\\) WHERE token_hash = \\?").
+			WithArgs(hashToken("reset-token")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE accounts SET password = \\? WHERE id = \\?").
+			WithArgs(HashPassword("new-password"), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE api_account_tokens SET used_at = UNIX_TIMESTAMP\\(\\) WHERE account_id = \\? AND purpose = \\?").
+			WithArgs(1, tokenPasswordReset).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM api_account_sessions WHERE account_id = \\?").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.ResetPassword(context.Background(), "reset-token", "new-password")
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UsedOrExpired", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT account_id, email FROM api_account_tokens").
+			WithArgs(hashToken("reset-token"), tokenPasswordReset).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "email"}))
+		mock.ExpectRollback()
+
+		err := repo.ResetPassword(context.Background(), "reset-token", "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountTokenRepository_VerifyEmail(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountTokenRepository(db)
+
+	expectRedeem := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT account_id, email FROM api_account_tokens").
+			WithArgs(hashToken("verify-token"), tokenEmailVerify).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "email"}).AddRow(1, "player@example.com"))
+		mock.ExpectExec("UPDATE api_account_tokens SET used_at").
+			WithArgs(hashToken("verify-token")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	t.Run("Verified", func(t *testing.T) {
+		expectRedeem()
+		mock.ExpectQuery("SELECT email FROM accounts WHERE id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("player@example.com"))
+		mock.ExpectExec("INSERT INTO api_account_verified_emails").
+			WithArgs(1, "player@example.com").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.VerifyEmail(context.Background(), "verify-token"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("EmailChanged", func(t *testing.T) {
+		expectRedeem()
+		mock.ExpectQuery("SELECT email FROM accounts WHERE id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("new@example.com"))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, repo.VerifyEmail(context.Background(), "verify-token"), ErrInvalidToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountTokenRepository_EmailVerified(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountTokenRepository(db)
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_account_verified_emails WHERE account_id = \\? AND email = \\?").
+		WithArgs(1, "player@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	verified, err := repo.EmailVerified(context.Background(), &Account{ID: 1, Email: "player@example.com"})
+
+	require.NoError(t, err)
+	assert.True(t, verified)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrVipListFull      = errors.New("vip list is full")
+	ErrVipEntryExists   = errors.New("player is already on the vip list")
+	ErrVipEntryNotFound = errors.New("player is not on the vip list")
+	ErrInvalidVipEntry  = fmt.Errorf("vip description must be at most %d characters and icon between 0 and %d", maxVipDescription, maxVipIcon)
+)
+
+// The client's limits for a VIP entry, matching the account_viplist columns.
+const (
+	maxVipDescription = 128
+	maxVipIcon        = 10
+)
+
+type VipEntry struct {
+	AccountID   int    `db:"account_id" json:"accountId"`
+	PlayerID    int    `db:"player_id" json:"playerId"`
+	Description string `db:"description" json:"description"`
+	Icon        int    `db:"icon" json:"icon"`
+	Notify      bool   `db:"notify" json:"notify"`
+}
+
+type VipEntryInput struct {
+	AccountID   int
+	PlayerID    int
+	Description string
+	Icon        int
+	Notify      bool
+}
+
+// VipLimits is how many VIP entries an account may have, following the
+// vipFreeLimit and vipPremiumLimit options of the TFS config.lua.
+type VipLimits struct {
+	Free    int
+	Premium int
+}
+
+func DefaultVipLimits() VipLimits {
+	return VipLimits{Free: 20, Premium: 100}
+}
+
+func (r *AccountStorageRepository) GetVipList(ctx context.Context, accountID int) ([]*VipEntry, error) {
+	var vipList []*VipEntry
+	query := `SELECT account_id, player_id, description, icon, notify
+	          FROM account_viplist WHERE account_id = ?`
+
+	if err := r.db.SelectContext(ctx, &vipList, query, accountID); err != nil {
+		return nil, fmt.Errorf("failed to get vip list: %w", err)
+	}
+
+	return vipList, nil
+}
+
+// AddVipEntry adds a player to an account's VIP list, refusing it once the
+// list holds as many entries as the account's premium status allows.
+func (r *AccountStorageRepository) AddVipEntry(ctx context.Context, input VipEntryInput) (*VipEntry, error) {
+	if err := validateVipEntry(input); err != nil {
+		return nil, err
+	}
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var premium bool
+		query := `SELECT premium_ends_at > UNIX_TIMESTAMP() FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &premium, query, input.AccountID); err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+
+		var entries []int
+		query = `SELECT player_id FROM account_viplist WHERE account_id = ?`
+		if err := tx.SelectContext(ctx, &entries, query, input.AccountID); err != nil {
+			return fmt.Errorf("failed to get vip list: %w", err)
+		}
+		for _, playerID := range entries {
+			if playerID == input.PlayerID {
+				return ErrVipEntryExists
+			}
+		}
+
+		limit := r.vipLimits.Free
+		if premium {
+			limit = r.vipLimits.Premium
+		}
+		if len(entries) >= limit {
+			return ErrVipListFull
+		}
+
+		query = `INSERT INTO account_viplist (account_id, player_id, description, icon, notify)
+		         VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, input.AccountID, input.PlayerID, input.Description,
+			input.Icon, input.Notify); err != nil {
+			return fmt.Errorf("failed to add vip entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vipEntryFromInput(input), nil
+}
+
+// UpdateVipEntry changes the description, icon and login notification of a
+// VIP entry.
+func (r *AccountStorageRepository) UpdateVipEntry(ctx context.Context, input VipEntryInput) (*VipEntry, error) {
+	if err := validateVipEntry(input); err != nil {
+		return nil, err
+	}
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var count int
+		query := `SELECT COUNT(*) FROM account_viplist WHERE account_id = ? AND player_id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &count, query, input.AccountID, input.PlayerID); err != nil {
+			return fmt.Errorf("failed to get vip entry: %w", err)
+		}
+		if count == 0 {
+			return ErrVipEntryNotFound
+		}
+
+		query = `UPDATE account_viplist SET description = ?, icon = ?, notify = ?
+		         WHERE account_id = ? AND player_id = ?`
+		if _, err := tx.ExecContext(ctx, query, input.Description, input.Icon, input.Notify,
+			input.AccountID, input.PlayerID); err != nil {
+			return fmt.Errorf("failed to update vip entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return vipEntryFromInput(input), nil
+}
+
+// RemoveVipEntry removes a player from an account's VIP list and reports
+// whether it was there.
+func (r *AccountStorageRepository) RemoveVipEntry(ctx context.Context, accountID, playerID int) (bool, error) {
+	query := `DELETE FROM account_viplist WHERE account_id = ? AND player_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, accountID, playerID)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove vip entry: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func validateVipEntry(input VipEntryInput) error {
+	if len(input.Description) > maxVipDescription || input.Icon < 0 || input.Icon > maxVipIcon {
+		return ErrInvalidVipEntry
+	}
+	return nil
+}
+
+func vipEntryFromInput(input VipEntryInput) *VipEntry {
+	return &VipEntry{
+		AccountID:   input.AccountID,
+		PlayerID:    input.PlayerID,
+		Description: input.Description,
+		Icon:        input.Icon,
+		Notify:      input.Notify,
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectVipListLock(mock sqlmock.Sqlmock, accountID int, premium bool, entries ...int) {
+	mock.ExpectQuery("SELECT premium_ends_at > UNIX_TIMESTAMP\\(\\) FROM accounts WHERE id = \\? FOR UPDATE").
+		WithArgs(accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"premium"}).AddRow(premium))
+
+	rows := sqlmock.NewRows([]string{"player_id"})
+	for _, playerID := range entries {
+		rows.AddRow(playerID)
+	}
+	mock.ExpectQuery("SELECT player_id FROM account_viplist WHERE account_id = \\?").
+		WithArgs(accountID).
+		WillReturnRows(rows)
+}
+
+func TestAccountStorageRepository_GetVipList(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	mock.ExpectQuery("SELECT account_id, player_id, description, icon, notify FROM account_viplist WHERE account_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "player_id", "description", "icon", "notify"}).
+			AddRow(1, 2, "Friend", 3, 1))
+
+	vipList, err := repo.GetVipList(context.Background(), 1)
+
+	require.NoError(t, err)
+	require.Len(t, vipList, 1)
+	assert.Equal(t, "Friend", vipList[0].Description)
+	assert.Equal(t, 3, vipList[0].Icon)
+	assert.True(t, vipList[0].Notify)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountStorageRepository_AddVipEntry(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+	repo.SetVipLimits(VipLimits{Free: 2, Premium: 3})
+
+	input := VipEntryInput{AccountID: 1, PlayerID: 5, Description: "Friend", Icon: 2, Notify: true}
+
+	t.Run("Added", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectVipListLock(mock, 1, false, 2)
+		mock.ExpectExec("INSERT INTO account_viplist \\(account_id, player_id, description, icon, notify\\)").
+			WithArgs(1, 5, "Friend", 2, true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		entry, err := repo.AddVipEntry(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, entry.PlayerID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FreeLimit", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectVipListLock(mock, 1, false, 2, 3)
+		mock.ExpectRollback()
+
+		_, err := repo.AddVipEntry(context.Background(), input)
+
+		assert.ErrorIs(t, err, ErrVipListFull)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PremiumLimit", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectVipListLock(mock, 1, true, 2, 3)
+		mock.ExpectExec("INSERT INTO account_viplist").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.AddVipEntry(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectVipListLock(mock, 1, false, 5)
+		mock.ExpectRollback()
+
+		_, err := repo.AddVipEntry(context.Background(), input)
+
+		assert.ErrorIs(t, err, ErrVipEntryExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := repo.AddVipEntry(context.Background(), VipEntryInput{AccountID: 1, PlayerID: 5, Icon: 11})
+		assert.ErrorIs(t, err, ErrInvalidVipEntry)
+
+		_, err = repo.AddVipEntry(context.Background(), VipEntryInput{
+			AccountID: 1, PlayerID: 5, Description: strings.Repeat("a", 129),
+		})
+		assert.ErrorIs(t, err, ErrInvalidVipEntry)
+	})
+}
+
+func TestAccountStorageRepository_UpdateVipEntry(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	input := VipEntryInput{AccountID: 1, PlayerID: 5, Description: "Enemy", Icon: 4}
+
+	t.Run("Updated", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM account_viplist WHERE account_id = \\? AND player_id = \\? FOR UPDATE").
+			WithArgs(1, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec("UPDATE account_viplist SET description = \\?, icon = \\?, notify = \\? WHERE account_id = \\? AND player_id = \\?").
+			WithArgs("Enemy", 4, false, 1, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		entry, err := repo.UpdateVipEntry(context.Background(), input)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Enemy", entry.Description)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM account_viplist").
+			WithArgs(1, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		_, err := repo.UpdateVipEntry(context.Background(), input)
+
+		assert.ErrorIs(t, err, ErrVipEntryNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAccountStorageRepository_RemoveVipEntry(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountStorageRepository(db)
+
+	mock.ExpectExec("DELETE FROM account_viplist WHERE account_id = \\? AND player_id = \\?").
+		WithArgs(1, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	removed, err := repo.RemoveVipEntry(context.Background(), 1, 5)
+
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
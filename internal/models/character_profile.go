@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// CharacterProfile is the public view of a character, as shown on a
+// character page.
+type CharacterProfile struct {
+	Player *Player `json:"player"`
+}
+
+func (p *CharacterProfile) LastLogin() int64 {
+	return p.Player.LastLogin
+}
+
+// HidesCharacters reports whether an account keeps its characters off each
+// other's profiles.
+func (r *AccountRepository) HidesCharacters(ctx context.Context, accountID int) (bool, error) {
+	var hide []bool
+	query := `SELECT hide_characters FROM api_account_preferences WHERE account_id = ?`
+	if err := r.db.SelectContext(ctx, &hide, query, accountID); err != nil {
+		return false, fmt.Errorf("failed to get account preferences: %w", err)
+	}
+
+	return len(hide) > 0 && hide[0], nil
+}
+
+// SetHideCharacters sets whether an account's characters are listed on each
+// other's profiles.
+func (r *AccountRepository) SetHideCharacters(ctx context.Context, accountID int, hide bool) error {
+	query := `INSERT INTO api_account_preferences (account_id, hide_characters) VALUES (?, ?)
+	          ON DUPLICATE KEY UPDATE hide_characters = VALUES(hide_characters)`
+	if _, err := r.db.ExecContext(ctx, query, accountID, hide); err != nil {
+		return fmt.Errorf("failed to set account preferences: %w", err)
+	}
+
+	return nil
+}
+
+// GetOtherCharacters returns the characters on an account besides playerID,
+// leaving out ones scheduled for deletion.
+func (r *PlayerRepository) GetOtherCharacters(ctx context.Context, accountID, playerID int) ([]*Player, error) {
+	var players []*Player
+	query := `
+		SELECT id, name, group_id, account_id, level, vocation, health, healthmax,
+		       experience, lookbody, lookfeet, lookhead, looklegs, looktype, lookaddons,
+		       maglevel, mana, manamax, soul, town_id, posx, posy, posz, cap, sex,
+		       lastlogin, balance, deletion
+		FROM players
+		WHERE account_id = ? AND id <> ? AND deletion = 0
+		ORDER BY name
+	`
+
+	if err := r.db.SelectContext(ctx, &players, query, accountID, playerID); err != nil {
+		return nil, fmt.Errorf("failed to get players: %w", err)
+	}
+
+	return players, nil
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountRepository_HidesCharacters(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectQuery("SELECT hide_characters FROM api_account_preferences WHERE account_id = \\?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"hide_characters"}))
+	mock.ExpectQuery("SELECT hide_characters FROM api_account_preferences").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"hide_characters"}).AddRow(true))
+
+	hidden, err := repo.HidesCharacters(context.Background(), 1)
+	require.NoError(t, err)
+	assert.False(t, hidden, "accounts without preferences show their characters")
+
+	hidden, err = repo.HidesCharacters(context.Background(), 2)
+	require.NoError(t, err)
+	assert.True(t, hidden)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccountRepository_SetHideCharacters(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectExec("INSERT INTO api_account_preferences \\(account_id, hide_characters\\) VALUES \\(\\?, \\?\\) "+
+		"ON DUPLICATE KEY UPDATE hide_characters = VALUES\\(hide_characters\\)").
+		WithArgs(1, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.SetHideCharacters(context.Background(), 1, true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_GetOtherCharacters(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectQuery("FROM players WHERE account_id = \\? AND id <> \\? AND deletion = 0 ORDER BY name").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "Alt"))
+
+	players, err := repo.GetOtherCharacters(context.Background(), 1, 2)
+
+	require.NoError(t, err)
+	require.Len(t, players, 1)
+	assert.Equal(t, "Alt", players[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_GetByName(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectQuery("FROM players WHERE name = \\?").
+		WithArgs("Galahad").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Galahad"))
+	mock.ExpectQuery("FROM players WHERE name = \\?").
+		WithArgs("Nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	player, err := repo.GetByName(context.Background(), " Galahad ")
+	require.NoError(t, err)
+	assert.Equal(t, 1, player.ID)
+
+	_, err = repo.GetByName(context.Background(), "Nobody")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHouseRepository_GetByOwner(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewHouseRepository(db)
+
+	mock.ExpectQuery("FROM houses WHERE owner = \\? ORDER BY id LIMIT 1").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner", "name"}).AddRow(7, 1, "Harbour Flat"))
+
+	house, err := repo.GetByOwner(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, house.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerDeathRepository_GetRecent(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerDeathRepository(db)
+
+	mock.ExpectQuery("FROM player_deaths WHERE player_id = \\? ORDER BY time DESC LIMIT \\?").
+		WithArgs(1, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id", "time", "level"}).AddRow(1, 100, 50))
+
+	deaths, err := repo.GetRecent(context.Background(), 1, 5)
+
+	require.NoError(t, err)
+	assert.Len(t, deaths, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
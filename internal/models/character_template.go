@@ -0,0 +1,113 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	SexFemale = 0
+	SexMale   = 1
+)
+
+// CharacterOutfit is the look a new character starts with.
+type CharacterOutfit struct {
+	Female int `json:"female"`
+	Male   int `json:"male"`
+	Head   int `json:"head"`
+	Body   int `json:"body"`
+	Legs   int `json:"legs"`
+	Feet   int `json:"feet"`
+}
+
+// LookType returns the outfit look type for sex.
+func (o CharacterOutfit) LookType(sex int) int {
+	if sex == SexFemale {
+		return o.Female
+	}
+	return o.Male
+}
+
+// CharacterSkills are the starting skill levels of a new character.
+type CharacterSkills struct {
+	Magic     int `json:"magic"`
+	Fist      int `json:"fist"`
+	Club      int `json:"club"`
+	Sword     int `json:"sword"`
+	Axe       int `json:"axe"`
+	Distance  int `json:"distance"`
+	Shielding int `json:"shielding"`
+	Fishing   int `json:"fishing"`
+}
+
+// CharacterTemplate describes how a new character of a vocation is created.
+type CharacterTemplate struct {
+	Vocation int             `json:"vocation"`
+	Level    int             `json:"level"`
+	Health   int             `json:"health"`
+	Mana     int             `json:"mana"`
+	Cap      int             `json:"cap"`
+	Soul     int             `json:"soul"`
+	TownID   int             `json:"townId"`
+	Outfit   CharacterOutfit `json:"outfit"`
+	Skills   CharacterSkills `json:"skills"`
+}
+
+// CharacterCreation holds the rules PlayerRepository.Create applies.
+type CharacterCreation struct {
+	MaxPerAccount int
+	Templates     map[int]CharacterTemplate
+}
+
+// DefaultCharacterCreation returns level 1 rookgaard characters for
+// vocation 0 and level 8 main characters for the four base vocations,
+// all starting in town 1 with the citizen outfit.
+func DefaultCharacterCreation() CharacterCreation {
+	outfit := CharacterOutfit{Female: 136, Male: 128, Head: 78, Body: 69, Legs: 58, Feet: 76}
+	skills := CharacterSkills{Fist: 10, Club: 10, Sword: 10, Axe: 10, Distance: 10, Shielding: 10, Fishing: 10}
+
+	templates := map[int]CharacterTemplate{
+		0: {Vocation: 0, Level: 1, Health: 150, Mana: 55, Cap: 400, Soul: 100, TownID: 1, Outfit: outfit, Skills: skills},
+	}
+	for vocation := 1; vocation <= 4; vocation++ {
+		templates[vocation] = CharacterTemplate{
+			Vocation: vocation, Level: 8, Health: 185, Mana: 90, Cap: 470, Soul: 100, TownID: 1,
+			Outfit: outfit, Skills: skills,
+		}
+	}
+
+	return CharacterCreation{MaxPerAccount: 10, Templates: templates}
+}
+
+// LoadCharacterTemplates reads a JSON array of templates, one per vocation.
+func LoadCharacterTemplates(path string) (map[int]CharacterTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read character templates: %w", err)
+	}
+
+	var list []CharacterTemplate
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse character templates: %w", err)
+	}
+
+	templates := make(map[int]CharacterTemplate, len(list))
+	for _, template := range list {
+		if template.Level < 1 {
+			return nil, fmt.Errorf("character template for vocation %d: level must be at least 1", template.Vocation)
+		}
+		if _, ok := templates[template.Vocation]; ok {
+			return nil, fmt.Errorf("duplicate character template for vocation %d", template.Vocation)
+		}
+		templates[template.Vocation] = template
+	}
+
+	return templates, nil
+}
+
+// ExperienceForLevel returns the experience TFS requires for level.
+func ExperienceForLevel(level int) int64 {
+	l := int64(level - 1)
+	return (50*l*l*l - 150*l*l + 400*l) / 3
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperienceForLevel(t *testing.T) {
+	assert.Equal(t, int64(0), ExperienceForLevel(1))
+	assert.Equal(t, int64(100), ExperienceForLevel(2))
+	assert.Equal(t, int64(4200), ExperienceForLevel(8))
+	assert.Equal(t, int64(15694800), ExperienceForLevel(100))
+}
+
+func TestCharacterOutfit_LookType(t *testing.T) {
+	outfit := DefaultCharacterCreation().Templates[1].Outfit
+
+	assert.Equal(t, 136, outfit.LookType(SexFemale))
+	assert.Equal(t, 128, outfit.LookType(SexMale))
+}
+
+func TestLoadCharacterTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	data := `[
+		{"vocation": 0, "level": 1, "health": 150, "mana": 55, "cap": 400, "townId": 3,
+		 "outfit": {"female": 136, "male": 128}, "skills": {"fist": 10}},
+		{"vocation": 4, "level": 8, "health": 185, "mana": 90, "cap": 470, "townId": 2,
+		 "outfit": {"female": 142, "male": 134}, "skills": {"sword": 12}}
+	]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	templates, err := LoadCharacterTemplates(path)
+
+	require.NoError(t, err)
+	assert.Len(t, templates, 2)
+	assert.Equal(t, 3, templates[0].TownID)
+	assert.Equal(t, 134, templates[4].Outfit.LookType(SexMale))
+	assert.Equal(t, 12, templates[4].Skills.Sword)
+}
+
+func TestLoadCharacterTemplates_Duplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.json")
+	data := `[{"vocation": 1, "level": 8}, {"vocation": 1, "level": 1}]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	_, err := LoadCharacterTemplates(path)
+
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 type Guild struct {
@@ -91,16 +92,27 @@ func (r *GuildRepository) GetAll(ctx context.Context) ([]*Guild, error) {
 }
 
 func (r *GuildRepository) Create(ctx context.Context, input CreateGuildInput) (*Guild, error) {
-	query := `INSERT INTO guilds (name, ownerid, creationdata) VALUES (?, ?, UNIX_TIMESTAMP())`
+	var id int64
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, input.OwnerID); err != nil {
+			return err
+		}
 
-	result, err := r.db.ExecContext(ctx, query, input.Name, input.OwnerID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create guild: %w", err)
-	}
+		query := `INSERT INTO guilds (name, ownerid, creationdata) VALUES (?, ?, UNIX_TIMESTAMP())`
+		result, err := tx.ExecContext(ctx, query, input.Name, input.OwnerID)
+		if err != nil {
+			return fmt.Errorf("failed to create guild: %w", err)
+		}
 
-	id, err := result.LastInsertId()
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return nil, err
 	}
 
 	return r.GetByID(ctx, int(id))
@@ -140,37 +152,47 @@ func (r *GuildRepository) GetMembershipByPlayerID(ctx context.Context, playerID
 }
 
 func (r *GuildRepository) InvitePlayer(ctx context.Context, guildID, playerID int) error {
-	query := `INSERT INTO guild_invites (player_id, guild_id) VALUES (?, ?)`
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
 
-	_, err := r.db.ExecContext(ctx, query, playerID, guildID)
-	if err != nil {
-		return fmt.Errorf("failed to invite player: %w", err)
-	}
+		query := `INSERT INTO guild_invites (player_id, guild_id) VALUES (?, ?)`
+		if _, err := tx.ExecContext(ctx, query, playerID, guildID); err != nil {
+			return fmt.Errorf("failed to invite player: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r *GuildRepository) AcceptInvite(ctx context.Context, guildID, playerID int) error {
-	// Get lowest rank (level 1)
-	var rankID int
-	query := `SELECT id FROM guild_ranks WHERE guild_id = ? AND level = 1 LIMIT 1`
-	if err := r.db.GetContext(ctx, &rankID, query, guildID); err != nil {
-		return fmt.Errorf("failed to get rank: %w", err)
-	}
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
 
-	// Add to guild_membership
-	query = `INSERT INTO guild_membership (player_id, guild_id, rank_id) VALUES (?, ?, ?)`
-	if _, err := r.db.ExecContext(ctx, query, playerID, guildID, rankID); err != nil {
-		return fmt.Errorf("failed to add member: %w", err)
-	}
+		// Get lowest rank (level 1)
+		var rankID int
+		query := `SELECT id FROM guild_ranks WHERE guild_id = ? AND level = 1 LIMIT 1`
+		if err := tx.GetContext(ctx, &rankID, query, guildID); err != nil {
+			return fmt.Errorf("failed to get rank: %w", err)
+		}
 
-	// Remove invite
-	query = `DELETE FROM guild_invites WHERE player_id = ? AND guild_id = ?`
-	if _, err := r.db.ExecContext(ctx, query, playerID, guildID); err != nil {
-		return fmt.Errorf("failed to remove invite: %w", err)
-	}
+		// Add to guild_membership
+		query = `INSERT INTO guild_membership (player_id, guild_id, rank_id) VALUES (?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, playerID, guildID, rankID); err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+
+		// Remove invite
+		query = `DELETE FROM guild_invites WHERE player_id = ? AND guild_id = ?`
+		if _, err := tx.ExecContext(ctx, query, playerID, guildID); err != nil {
+			return fmt.Errorf("failed to remove invite: %w", err)
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r *GuildRepository) GetWars(ctx context.Context, guildID *int) ([]*GuildWar, error) {
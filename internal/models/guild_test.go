@@ -68,9 +68,12 @@ func TestGuildRepository_Create(t *testing.T) {
 		OwnerID: 1,
 	}
 
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
 	mock.ExpectExec("INSERT INTO guilds").
 		WithArgs(input.Name, input.OwnerID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{"id", "name", "ownerid", "creationdata", "motd"}).
 		AddRow(1, input.Name, input.OwnerID, 1234567890, "")
@@ -165,9 +168,12 @@ func TestGuildRepository_InvitePlayer(t *testing.T) {
 
 	repo := NewGuildRepository(db)
 
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 2, false)
 	mock.ExpectExec("INSERT INTO guild_invites").
 		WithArgs(2, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	err = repo.InvitePlayer(context.Background(), 1, 2)
 
@@ -182,6 +188,9 @@ func TestGuildRepository_AcceptInvite(t *testing.T) {
 
 	repo := NewGuildRepository(db)
 
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 2, false)
+
 	// Mock getting rank
 	rankRows := sqlmock.NewRows([]string{"id"}).AddRow(1)
 	mock.ExpectQuery("SELECT id FROM guild_ranks WHERE guild_id = \\? AND level = 1 LIMIT 1").
@@ -197,6 +206,7 @@ func TestGuildRepository_AcceptInvite(t *testing.T) {
 	mock.ExpectExec("DELETE FROM guild_invites WHERE player_id = \\? AND guild_id = \\?").
 		WithArgs(2, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	err = repo.AcceptInvite(context.Background(), 1, 2)
 
@@ -204,6 +214,61 @@ func TestGuildRepository_AcceptInvite(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGuildRepository_Online(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewGuildRepository(db)
+
+	t.Run("Create", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		guild, err := repo.Create(context.Background(), CreateGuildInput{Name: "New Guild", OwnerID: 1})
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.Nil(t, guild)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvitePlayer", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 2, true)
+		mock.ExpectRollback()
+
+		err := repo.InvitePlayer(context.Background(), 1, 2)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AcceptInvite", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 2, true)
+		mock.ExpectRollback()
+
+		err := repo.AcceptInvite(context.Background(), 1, 2)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StaffOverride", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO guild_invites").
+			WithArgs(2, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.InvitePlayer(WithOnlineOverride(context.Background()), 1, 2)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestGuildRepository_GetWars(t *testing.T) {
 	db, mock, err := NewMockDB()
 	require.NoError(t, err)
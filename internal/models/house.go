@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 type House struct {
@@ -49,6 +50,19 @@ func (r *HouseRepository) GetByID(ctx context.Context, id int) (*House, error) {
 	return &house, nil
 }
 
+// GetByOwner returns the house a player owns.
+func (r *HouseRepository) GetByOwner(ctx context.Context, playerID int) (*House, error) {
+	var house House
+	query := `SELECT id, owner, paid, warnings, name, rent, town_id, bid, bid_end, last_bid,
+	          highest_bidder, size, beds FROM houses WHERE owner = ? ORDER BY id LIMIT 1`
+
+	if err := r.db.GetContext(ctx, &house, query, playerID); err != nil {
+		return nil, fmt.Errorf("failed to get house: %w", err)
+	}
+
+	return &house, nil
+}
+
 func (r *HouseRepository) GetByTownID(ctx context.Context, townID *int) ([]*House, error) {
 	var houses []*House
 	var query string
@@ -71,12 +85,21 @@ func (r *HouseRepository) GetByTownID(ctx context.Context, townID *int) ([]*Hous
 }
 
 func (r *HouseRepository) PlaceBid(ctx context.Context, houseID, playerID, bidAmount int) (*House, error) {
-	query := `UPDATE houses SET bid = ?, highest_bidder = ?, last_bid = UNIX_TIMESTAMP()
-	          WHERE id = ?`
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := `UPDATE houses SET bid = ?, highest_bidder = ?, last_bid = UNIX_TIMESTAMP()
+		          WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, bidAmount, playerID, houseID); err != nil {
+			return fmt.Errorf("failed to place bid: %w", err)
+		}
 
-	_, err := r.db.ExecContext(ctx, query, bidAmount, playerID, houseID)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to place bid: %w", err)
+		return nil, err
 	}
 
 	return r.GetByID(ctx, houseID)
@@ -89,9 +89,12 @@ func TestHouseRepository_PlaceBid(t *testing.T) {
 
 	repo := NewHouseRepository(db)
 
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
 	mock.ExpectExec("UPDATE houses SET bid = \\?, highest_bidder = \\?, last_bid = UNIX_TIMESTAMP\\(\\) WHERE id = \\?").
 		WithArgs(5000, 1, 1).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{
 		"id", "owner", "paid", "warnings", "name", "rent", "town_id", "bid", "bid_end",
@@ -109,3 +112,40 @@ func TestHouseRepository_PlaceBid(t *testing.T) {
 	assert.Equal(t, 1, house.HighestBidder)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestHouseRepository_PlaceBid_Online(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewHouseRepository(db)
+
+	t.Run("Rejected", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		house, err := repo.PlaceBid(context.Background(), 1, 1, 5000)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.Nil(t, house)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StaffOverride", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE houses SET bid").
+			WithArgs(5000, 1, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT (.+) FROM houses WHERE id = \\?").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bid", "highest_bidder"}).AddRow(1, 5000, 1))
+
+		house, err := repo.PlaceBid(WithOnlineOverride(context.Background()), 1, 1, 5000)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5000, house.Bid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+)
+
+type IpBan struct {
+	RawIP     uint32 `db:"ip" json:"-"`
+	Reason    string `db:"reason" json:"reason"`
+	BannedAt  int64  `db:"banned_at" json:"bannedAt"`
+	ExpiresAt int64  `db:"expires_at" json:"expiresAt"`
+	BannedBy  int    `db:"banned_by" json:"bannedBy"`
+}
+
+// IP returns the banned address in dotted IPv4 notation.
+func (b *IpBan) IP() string {
+	return IntToIP(b.RawIP)
+}
+
+type BanIpInput struct {
+	IP        string
+	Reason    string
+	ExpiresAt int64
+	// Set by the API to the configured moderator player, not by clients
+	BannedBy int
+}
+
+// IPToInt converts a dotted IPv4 address to the integer TFS stores in
+// ip_bans and players.lastip. TFS keeps the address in network byte order
+// read as a little-endian integer, so 1.2.3.4 is stored as 0x04030201.
+func IPToInt(ip string) (uint32, error) {
+	parsed := net.ParseIP(ip).To4()
+	if parsed == nil {
+		return 0, fmt.Errorf("invalid IPv4 address: %q", ip)
+	}
+
+	return uint32(parsed[0]) | uint32(parsed[1])<<8 | uint32(parsed[2])<<16 | uint32(parsed[3])<<24, nil
+}
+
+// IntToIP converts an address stored by TFS back to dotted IPv4 notation.
+func IntToIP(ip uint32) string {
+	return net.IPv4(byte(ip), byte(ip>>8), byte(ip>>16), byte(ip>>24)).String()
+}
+
+type IpBanRepository struct {
+	db *database.DB
+}
+
+func NewIpBanRepository(db *database.DB) *IpBanRepository {
+	return &IpBanRepository{db: db}
+}
+
+func (r *IpBanRepository) GetAll(ctx context.Context) ([]*IpBan, error) {
+	var bans []*IpBan
+	query := `SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans ORDER BY banned_at DESC`
+
+	if err := r.db.SelectContext(ctx, &bans, query); err != nil {
+		return nil, fmt.Errorf("failed to get ip bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+func (r *IpBanRepository) GetByIP(ctx context.Context, ip string) (*IpBan, error) {
+	rawIP, err := IPToInt(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	var ban IpBan
+	query := `SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans WHERE ip = ?`
+
+	if err := r.db.GetContext(ctx, &ban, query, rawIP); err != nil {
+		return nil, fmt.Errorf("failed to get ip ban: %w", err)
+	}
+
+	return &ban, nil
+}
+
+func (r *IpBanRepository) Create(ctx context.Context, input BanIpInput) (*IpBan, error) {
+	rawIP, err := IPToInt(input.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO ip_bans (ip, reason, banned_at, expires_at, banned_by)
+	          VALUES (?, ?, UNIX_TIMESTAMP(), ?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, rawIP, input.Reason, input.ExpiresAt, input.BannedBy); err != nil {
+		return nil, fmt.Errorf("failed to create ip ban: %w", err)
+	}
+
+	return r.GetByIP(ctx, input.IP)
+}
+
+// Delete lifts the ban on ip. It reports whether a ban existed.
+func (r *IpBanRepository) Delete(ctx context.Context, ip string) (bool, error) {
+	rawIP, err := IPToInt(ip)
+	if err != nil {
+		return false, err
+	}
+
+	query := `DELETE FROM ip_bans WHERE ip = ?`
+
+	result, err := r.db.ExecContext(ctx, query, rawIP)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete ip ban: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPToInt(t *testing.T) {
+	ip, err := IPToInt("1.2.3.4")
+
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x04030201), ip)
+	assert.Equal(t, "1.2.3.4", IntToIP(ip))
+}
+
+func TestIPToInt_Invalid(t *testing.T) {
+	for _, ip := range []string{"", "1.2.3", "256.0.0.1", "::1"} {
+		_, err := IPToInt(ip)
+		assert.Error(t, err, ip)
+	}
+}
+
+func TestIpBanRepository_GetAll(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewIpBanRepository(db)
+
+	rows := sqlmock.NewRows([]string{"ip", "reason", "banned_at", "expires_at", "banned_by"}).
+		AddRow(uint32(0x0100007f), "Botting", 1234567890, 1234567900, 1)
+
+	mock.ExpectQuery("SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans").
+		WillReturnRows(rows)
+
+	bans, err := repo.GetAll(context.Background())
+
+	require.NoError(t, err)
+	assert.Len(t, bans, 1)
+	assert.Equal(t, "127.0.0.1", bans[0].IP())
+	assert.Equal(t, "Botting", bans[0].Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIpBanRepository_Create(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewIpBanRepository(db)
+
+	input := BanIpInput{
+		IP:        "10.0.0.2",
+		Reason:    "Mass account creation",
+		ExpiresAt: 1234567900,
+		BannedBy:  1,
+	}
+
+	mock.ExpectExec("INSERT INTO ip_bans").
+		WithArgs(uint32(0x0200000a), input.Reason, input.ExpiresAt, input.BannedBy).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rows := sqlmock.NewRows([]string{"ip", "reason", "banned_at", "expires_at", "banned_by"}).
+		AddRow(uint32(0x0200000a), input.Reason, 1234567890, input.ExpiresAt, input.BannedBy)
+
+	mock.ExpectQuery("SELECT ip, reason, banned_at, expires_at, banned_by FROM ip_bans WHERE ip = ?").
+		WithArgs(uint32(0x0200000a)).
+		WillReturnRows(rows)
+
+	ban, err := repo.Create(context.Background(), input)
+
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2", ban.IP())
+	assert.Equal(t, input.Reason, ban.Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIpBanRepository_Create_InvalidIP(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewIpBanRepository(db)
+
+	ban, err := repo.Create(context.Background(), BanIpInput{IP: "not-an-ip"})
+
+	assert.Error(t, err)
+	assert.Nil(t, ban)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIpBanRepository_Delete(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewIpBanRepository(db)
+
+	mock.ExpectExec("DELETE FROM ip_bans WHERE ip = ?").
+		WithArgs(uint32(0x0100007f)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	deleted, err := repo.Delete(context.Background(), "127.0.0.1")
+
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 type MarketOffer struct {
@@ -69,22 +70,34 @@ func (r *MarketRepository) GetOffers(ctx context.Context, itemType *int) ([]*Mar
 }
 
 func (r *MarketRepository) CreateOffer(ctx context.Context, input CreateMarketOfferInput) (*MarketOffer, error) {
-	query := `INSERT INTO market_offers (player_id, sale, itemtype, amount, created, anonymous, price)
-	          VALUES (?, ?, ?, ?, UNIX_TIMESTAMP(), ?, ?)`
+	var id int64
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, input.PlayerID); err != nil {
+			return err
+		}
 
-	result, err := r.db.ExecContext(ctx, query, input.PlayerID, input.Sale, input.ItemType,
-		input.Amount, input.Anonymous, input.Price)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create market offer: %w", err)
-	}
+		query := `INSERT INTO market_offers (player_id, sale, itemtype, amount, created, anonymous, price)
+		          VALUES (?, ?, ?, ?, UNIX_TIMESTAMP(), ?, ?)`
+
+		result, err := tx.ExecContext(ctx, query, input.PlayerID, input.Sale, input.ItemType,
+			input.Amount, input.Anonymous, input.Price)
+		if err != nil {
+			return fmt.Errorf("failed to create market offer: %w", err)
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
 
-	id, err := result.LastInsertId()
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return nil, err
 	}
 
 	var offer MarketOffer
-	query = `SELECT id, player_id, sale, itemtype, amount, created, anonymous, price
+	query := `SELECT id, player_id, sale, itemtype, amount, created, anonymous, price
 	         FROM market_offers WHERE id = ?`
 
 	if err := r.db.GetContext(ctx, &offer, query, id); err != nil {
@@ -105,3 +118,20 @@ func (r *MarketRepository) GetHistory(ctx context.Context, playerID int) ([]*Mar
 
 	return history, nil
 }
+
+// MarketOfferCounts is the number of open market offers on each side.
+type MarketOfferCounts struct {
+	Buy  int `db:"buy" json:"buy"`
+	Sell int `db:"sell" json:"sell"`
+}
+
+// CountOffers counts the open buy and sell offers.
+func (r *MarketRepository) CountOffers(ctx context.Context) (*MarketOfferCounts, error) {
+	var counts MarketOfferCounts
+	query := `SELECT COALESCE(SUM(sale = 0), 0) AS buy, COALESCE(SUM(sale = 1), 0) AS sell FROM market_offers`
+	if err := r.db.GetContext(ctx, &counts, query); err != nil {
+		return nil, fmt.Errorf("failed to count market offers: %w", err)
+	}
+
+	return &counts, nil
+}
@@ -68,9 +68,12 @@ func TestMarketRepository_CreateOffer(t *testing.T) {
 		Anonymous: false,
 	}
 
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
 	mock.ExpectExec("INSERT INTO market_offers").
 		WithArgs(input.PlayerID, input.Sale, input.ItemType, input.Amount, input.Anonymous, input.Price).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	rows := sqlmock.NewRows([]string{
 		"id", "player_id", "sale", "itemtype", "amount", "created", "anonymous", "price",
@@ -89,6 +92,44 @@ func TestMarketRepository_CreateOffer(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestMarketRepository_CreateOffer_Online(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewMarketRepository(db)
+
+	input := CreateMarketOfferInput{PlayerID: 1, Sale: true, ItemType: 2160, Amount: 10, Price: 1000}
+
+	t.Run("Rejected", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		offer, err := repo.CreateOffer(context.Background(), input)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.Nil(t, offer)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StaffOverride", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO market_offers").
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT (.+) FROM market_offers WHERE id = \\?").
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "player_id"}).AddRow(7, 1))
+
+		offer, err := repo.CreateOffer(WithOnlineOverride(context.Background()), input)
+
+		require.NoError(t, err)
+		assert.Equal(t, 7, offer.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestMarketRepository_GetHistory(t *testing.T) {
 	db, mock, err := NewMockDB()
 	require.NoError(t, err)
@@ -114,3 +155,19 @@ func TestMarketRepository_GetHistory(t *testing.T) {
 	assert.Equal(t, 2160, history[0].ItemType)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestMarketRepository_CountOffers(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewMarketRepository(db)
+
+	mock.ExpectQuery("SELECT COALESCE\\(SUM\\(sale = 0\\), 0\\) AS buy, COALESCE\\(SUM\\(sale = 1\\), 0\\) AS sell FROM market_offers").
+		WillReturnRows(sqlmock.NewRows([]string{"buy", "sell"}).AddRow(3, 5))
+
+	counts, err := repo.CountOffers(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &MarketOfferCounts{Buy: 3, Sell: 5}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+)
+
+// NameRule identifies which character name rule a name failed.
+type NameRule string
+
+const (
+	NameRuleLength         NameRule = "LENGTH"
+	NameRuleCharacters     NameRule = "CHARACTERS"
+	NameRuleWords          NameRule = "WORDS"
+	NameRuleCapitalization NameRule = "CAPITALIZATION"
+	NameRuleReservedPrefix NameRule = "RESERVED_PREFIX"
+	NameRuleBlockedWord    NameRule = "BLOCKED_WORD"
+	NameRuleTaken          NameRule = "TAKEN"
+	NameRuleNamelocked     NameRule = "NAMELOCKED"
+	NameRuleRecentlyUsed   NameRule = "RECENTLY_USED"
+)
+
+// NameError reports a character name rejected by the NameValidator.
+type NameError struct {
+	Rule    NameRule
+	Message string
+}
+
+func (e *NameError) Error() string {
+	return "invalid name: " + e.Message
+}
+
+var ErrNameTaken = &NameError{Rule: NameRuleTaken, Message: "name is already in use"}
+
+// NameRules configures the NameValidator. The defaults follow what the
+// TFS login and game servers accept.
+type NameRules struct {
+	MinLength        int
+	MaxLength        int
+	MaxWords         int
+	MinWordLength    int
+	ReservedPrefixes []string
+	BlockedWords     []string
+
+	// A name given up in a rename stays reserved for its former owner
+	// for this long
+	HistoryCooldown time.Duration
+}
+
+func DefaultNameRules() NameRules {
+	return NameRules{
+		MinLength:        3,
+		MaxLength:        29,
+		MaxWords:         3,
+		MinWordLength:    2,
+		ReservedPrefixes: []string{"GM", "CM", "God"},
+		HistoryCooldown:  30 * 24 * time.Hour,
+	}
+}
+
+type NameValidator struct {
+	db    *database.DB
+	rules NameRules
+}
+
+func NewNameValidator(db *database.DB, rules NameRules) *NameValidator {
+	return &NameValidator{db: db, rules: rules}
+}
+
+// Validate checks name against the format rules and makes sure no other
+// player uses it or gave it up within the history cooldown. excludePlayerID
+// lets a player keep or reclaim its own name when renaming; pass 0 for new
+// characters.
+func (v *NameValidator) Validate(ctx context.Context, name string, excludePlayerID int) error {
+	if err := v.ValidateFormat(name); err != nil {
+		return err
+	}
+
+	var row struct {
+		ID         int  `db:"id"`
+		Namelocked bool `db:"namelocked"`
+	}
+	query := `SELECT p.id, nl.player_id IS NOT NULL AS namelocked
+	          FROM players p LEFT JOIN player_namelocks nl ON nl.player_id = p.id
+	          WHERE LOWER(p.name) = LOWER(?) AND p.id <> ? LIMIT 1`
+
+	err := v.db.GetContext(ctx, &row, query, name, excludePlayerID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to check name: %w", err)
+	case row.Namelocked:
+		return &NameError{Rule: NameRuleNamelocked, Message: "name belongs to a namelocked player"}
+	default:
+		return ErrNameTaken
+	}
+
+	var count int
+	query = `SELECT COUNT(*) FROM api_player_name_history
+	         WHERE LOWER(old_name) = LOWER(?) AND player_id <> ? AND changed_at > UNIX_TIMESTAMP() - ?`
+
+	if err := v.db.GetContext(ctx, &count, query, name, excludePlayerID, int64(v.rules.HistoryCooldown.Seconds())); err != nil {
+		return fmt.Errorf("failed to check name history: %w", err)
+	}
+	if count > 0 {
+		return &NameError{Rule: NameRuleRecentlyUsed, Message: "name was recently given up by another player"}
+	}
+
+	return nil
+}
+
+// ValidateFormat checks name against the rules that don't need the database.
+func (v *NameValidator) ValidateFormat(name string) error {
+	if len(name) < v.rules.MinLength || len(name) > v.rules.MaxLength {
+		return &NameError{
+			Rule:    NameRuleLength,
+			Message: fmt.Sprintf("must be between %d and %d characters", v.rules.MinLength, v.rules.MaxLength),
+		}
+	}
+
+	for _, c := range name {
+		if c > unicode.MaxASCII || !(unicode.IsLetter(c) || c == ' ' || c == '\'' || c == '-') {
+			return &NameError{Rule: NameRuleCharacters, Message: "only letters, spaces, apostrophes and hyphens are allowed"}
+		}
+	}
+
+	words := strings.Split(name, " ")
+	for _, word := range words {
+		if word == "" {
+			return &NameError{Rule: NameRuleCharacters, Message: "must not start or end with a space or contain double spaces"}
+		}
+		if !unicode.IsLetter(rune(word[0])) {
+			return &NameError{Rule: NameRuleCharacters, Message: "each word must start with a letter"}
+		}
+	}
+
+	firstWord := strings.ToLower(words[0])
+	for _, prefix := range v.rules.ReservedPrefixes {
+		if firstWord == strings.ToLower(prefix) {
+			return &NameError{Rule: NameRuleReservedPrefix, Message: fmt.Sprintf("must not start with %q", prefix)}
+		}
+	}
+
+	lowerName := strings.ToLower(name)
+	for _, blocked := range v.rules.BlockedWords {
+		if blocked != "" && strings.Contains(lowerName, strings.ToLower(blocked)) {
+			return &NameError{Rule: NameRuleBlockedWord, Message: "contains a blocked word"}
+		}
+	}
+
+	if len(words) > v.rules.MaxWords {
+		return &NameError{Rule: NameRuleWords, Message: fmt.Sprintf("must have at most %d words", v.rules.MaxWords)}
+	}
+
+	for _, word := range words {
+		if len(word) < v.rules.MinWordLength {
+			return &NameError{Rule: NameRuleWords, Message: fmt.Sprintf("each word must have at least %d letters", v.rules.MinWordLength)}
+		}
+		for i, c := range word {
+			if i > 0 && unicode.IsUpper(c) && word[i-1] != '\'' && word[i-1] != '-' {
+				return &NameError{Rule: NameRuleCapitalization, Message: "capital letters are only allowed at the start of a word"}
+			}
+		}
+	}
+
+	if !unicode.IsUpper(rune(name[0])) {
+		return &NameError{Rule: NameRuleCapitalization, Message: "must start with a capital letter"}
+	}
+
+	return nil
+}
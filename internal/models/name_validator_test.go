@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameValidator_ValidateFormat(t *testing.T) {
+	rules := DefaultNameRules()
+	rules.BlockedWords = []string{"admin"}
+	validator := NewNameValidator(nil, rules)
+
+	tests := []struct {
+		name string
+		rule NameRule
+	}{
+		{"Sir Lancelot", ""},
+		{"Bubble", ""},
+		{"O'Neil", ""},
+		{"Mary-Jane Watson", ""},
+		{"Knight of Thais", ""},
+		{"Al", NameRuleLength},
+		{"Abcdefghijklmnopqrstuvwxyzabcd", NameRuleLength},
+		{"  a", NameRuleCharacters},
+		{"Bob ", NameRuleCharacters},
+		{"Bob  Smith", NameRuleCharacters},
+		{"Bob3", NameRuleCharacters},
+		{"Bób", NameRuleCharacters},
+		{"Bob 'Smith", NameRuleCharacters},
+		{"GM Bob", NameRuleReservedPrefix},
+		{"god Bob", NameRuleReservedPrefix},
+		{"The Admin", NameRuleBlockedWord},
+		{"One Two Three Four", NameRuleWords},
+		{"Bob A", NameRuleWords},
+		{"BoB", NameRuleCapitalization},
+		{"bob", NameRuleCapitalization},
+	}
+
+	for _, tt := range tests {
+		err := validator.ValidateFormat(tt.name)
+		if tt.rule == "" {
+			assert.NoError(t, err, tt.name)
+			continue
+		}
+
+		var nameErr *NameError
+		if assert.ErrorAs(t, err, &nameErr, tt.name) {
+			assert.Equal(t, tt.rule, nameErr.Rule, tt.name)
+		}
+	}
+}
+
+func TestNameValidator_Validate_Available(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	validator := NewNameValidator(db, DefaultNameRules())
+
+	mock.ExpectQuery("SELECT p.id, nl.player_id IS NOT NULL AS namelocked FROM players p").
+		WithArgs("Sir Lancelot", 0).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_player_name_history").
+		WithArgs("Sir Lancelot", 0, int64(2592000)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err = validator.Validate(context.Background(), "Sir Lancelot", 0)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNameValidator_Validate_RecentlyUsed(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	validator := NewNameValidator(db, DefaultNameRules())
+
+	mock.ExpectQuery("SELECT p.id, nl.player_id IS NOT NULL AS namelocked FROM players p").
+		WithArgs("Sir Lancelot", 0).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM api_player_name_history").
+		WithArgs("Sir Lancelot", 0, int64(2592000)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	err = validator.Validate(context.Background(), "Sir Lancelot", 0)
+
+	var nameErr *NameError
+	require.ErrorAs(t, err, &nameErr)
+	assert.Equal(t, NameRuleRecentlyUsed, nameErr.Rule)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNameValidator_Validate_Namelocked(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	validator := NewNameValidator(db, DefaultNameRules())
+
+	mock.ExpectQuery("SELECT p.id, nl.player_id IS NOT NULL AS namelocked FROM players p").
+		WithArgs("Sir Lancelot", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "namelocked"}).AddRow(9, true))
+
+	err = validator.Validate(context.Background(), "Sir Lancelot", 5)
+
+	var nameErr *NameError
+	require.ErrorAs(t, err, &nameErr)
+	assert.Equal(t, NameRuleNamelocked, nameErr.Rule)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
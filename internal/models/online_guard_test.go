@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectOnlineCheck(mock sqlmock.Sqlmock, playerID int, online bool) {
+	count := 0
+	if online {
+		count = 1
+	}
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players_online WHERE player_id = \\?$").
+		WithArgs(playerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestGuardOffline(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	t.Run("Offline", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOnlineCheck(mock, 2, false)
+		mock.ExpectCommit()
+
+		err := db.Transaction(context.Background(), func(tx *sqlx.Tx) error {
+			return guardOffline(context.Background(), tx, 1, 2)
+		})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Online", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOnlineCheck(mock, 2, true)
+		mock.ExpectRollback()
+
+		err := db.Transaction(context.Background(), func(tx *sqlx.Tx) error {
+			return guardOffline(context.Background(), tx, 1, 2)
+		})
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		var onlineErr *PlayerOnlineError
+		require.True(t, errors.As(err, &onlineErr))
+		assert.Equal(t, 2, onlineErr.PlayerID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		ctx := WithOnlineOverride(context.Background())
+		err := db.Transaction(ctx, func(tx *sqlx.Tx) error {
+			return guardOffline(ctx, tx, 1)
+		})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+)
+
+// maxOnlineHistoryHours bounds how far back the online history can be read.
+const maxOnlineHistoryHours = 90 * 24
+
+var ErrInvalidHistoryRange = errors.New("hours must be between 1 and 2160")
+
+// OnlineSample is the number of players online at one point in time.
+type OnlineSample struct {
+	SampledAt     int64 `db:"sampled_at" json:"sampledAt"`
+	PlayersOnline int   `db:"players_online" json:"playersOnline"`
+}
+
+type OnlineHistoryRepository struct {
+	db *database.DB
+}
+
+func NewOnlineHistoryRepository(db *database.DB) *OnlineHistoryRepository {
+	return &OnlineHistoryRepository{db: db}
+}
+
+// Record stores the current number of players online.
+func (r *OnlineHistoryRepository) Record(ctx context.Context, playersOnline int) error {
+	query := `INSERT INTO api_online_history (sampled_at, players_online) VALUES (UNIX_TIMESTAMP(), ?)`
+	if _, err := r.db.ExecContext(ctx, query, playersOnline); err != nil {
+		return fmt.Errorf("failed to record online count: %w", err)
+	}
+	return nil
+}
+
+// GetHistory returns the samples taken in the last hours, oldest first.
+func (r *OnlineHistoryRepository) GetHistory(ctx context.Context, hours int) ([]*OnlineSample, error) {
+	if hours < 1 || hours > maxOnlineHistoryHours {
+		return nil, ErrInvalidHistoryRange
+	}
+
+	var samples []*OnlineSample
+	query := `SELECT sampled_at, players_online FROM api_online_history
+	          WHERE sampled_at >= UNIX_TIMESTAMP() - ?
+	          ORDER BY sampled_at`
+	if err := r.db.SelectContext(ctx, &samples, query, hours*60*60); err != nil {
+		return nil, fmt.Errorf("failed to get online history: %w", err)
+	}
+
+	return samples, nil
+}
+
+// DeleteBefore removes samples taken before the given unix time, returning
+// how many were removed.
+func (r *OnlineHistoryRepository) DeleteBefore(ctx context.Context, before int64) (int64, error) {
+	query := `DELETE FROM api_online_history WHERE sampled_at < ?`
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete online history: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected, nil
+}
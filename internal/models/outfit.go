@@ -0,0 +1,74 @@
+package models
+
+// outfitPairs lists the female and male look types of each outfit, matching
+// the pairs in TFS's data/XML/outfits.xml.
+var outfitPairs = [][2]int{
+	{136, 128}, // Citizen
+	{137, 129}, // Hunter
+	{138, 130}, // Mage
+	{139, 131}, // Knight
+	{140, 132}, // Nobleman
+	{141, 133}, // Summoner
+	{142, 134}, // Warrior
+	{147, 143}, // Barbarian
+	{148, 144}, // Druid
+	{149, 145}, // Wizard
+	{150, 146}, // Oriental
+	{155, 151}, // Pirate
+	{156, 152}, // Assassin
+	{157, 153}, // Beggar
+	{158, 154}, // Shaman
+	{252, 251}, // Norseman
+	{269, 268}, // Nightmare
+	{270, 273}, // Jester
+	{279, 278}, // Brotherhood
+	{288, 289}, // Demonhunter
+	{324, 325}, // Yalaharian
+	{329, 328}, // Newly Wed
+	{336, 335}, // Warmaster
+	{366, 367}, // Wayfarer
+	{431, 430}, // Afflicted
+	{433, 432}, // Elementalist
+	{464, 463}, // Deepling
+	{466, 465}, // Insectoid
+	{471, 472}, // Entrepreneur
+	{513, 512}, // Crystal Warlord
+	{514, 516}, // Soil Guardian
+	{542, 541}, // Demon
+	{575, 574}, // Cave Explorer
+	{578, 577}, // Dream Warden
+	{618, 610}, // Glooth Engineer
+	{620, 619}, // Jersey
+	{632, 633}, // Champion
+	{635, 634}, // Conjurer
+	{636, 637}, // Beastmaster
+	{664, 665}, // Chaos Acolyte
+	{666, 667}, // Death Herald
+	{683, 684}, // Ranger
+	{694, 695}, // Ceremonial Garb
+	{696, 697}, // Puppeteer
+	{698, 699}, // Spirit Caller
+	{724, 725}, // Evoker
+	{732, 733}, // Seaweaver
+	{745, 746}, // Recruiter
+	{749, 750}, // Sea Dog
+	{759, 760}, // Royal Pumpkin
+}
+
+// OutfitForSex returns the look type of the same outfit for sex. Look types
+// that aren't part of a known pair fall back to the citizen outfit.
+func OutfitForSex(lookType, sex int) int {
+	for _, pair := range outfitPairs {
+		if pair[0] == lookType || pair[1] == lookType {
+			if sex == SexFemale {
+				return pair[0]
+			}
+			return pair[1]
+		}
+	}
+
+	if sex == SexFemale {
+		return outfitPairs[0][0]
+	}
+	return outfitPairs[0][1]
+}
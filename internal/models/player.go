@@ -2,11 +2,17 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
+var ErrCharacterLimit = errors.New("account has reached the character limit")
+
 type Player struct {
 	ID         int    `db:"id" json:"id"`
 	Name       string `db:"name" json:"name"`
@@ -35,6 +41,7 @@ type Player struct {
 	Sex        int    `db:"sex" json:"sex"`
 	LastLogin  int64  `db:"lastlogin" json:"lastLogin"`
 	Balance    int64  `db:"balance" json:"balance"`
+	Deletion   int64  `db:"deletion" json:"deletion"`
 }
 
 type CreatePlayerInput struct {
@@ -45,11 +52,17 @@ type CreatePlayerInput struct {
 }
 
 type PlayerRepository struct {
-	db *database.DB
+	db       *database.DB
+	creation CharacterCreation
 }
 
 func NewPlayerRepository(db *database.DB) *PlayerRepository {
-	return &PlayerRepository{db: db}
+	return &PlayerRepository{db: db, creation: DefaultCharacterCreation()}
+}
+
+// SetCharacterCreation replaces the templates and limits used by Create.
+func (r *PlayerRepository) SetCharacterCreation(creation CharacterCreation) {
+	r.creation = creation
 }
 
 func (r *PlayerRepository) GetByID(ctx context.Context, id int) (*Player, error) {
@@ -58,7 +71,7 @@ func (r *PlayerRepository) GetByID(ctx context.Context, id int) (*Player, error)
 		SELECT id, name, group_id, account_id, level, vocation, health, healthmax,
 		       experience, lookbody, lookfeet, lookhead, looklegs, looktype, lookaddons,
 		       maglevel, mana, manamax, soul, town_id, posx, posy, posz, cap, sex,
-		       lastlogin, balance
+		       lastlogin, balance, deletion
 		FROM players
 		WHERE id = ?
 	`
@@ -70,13 +83,54 @@ func (r *PlayerRepository) GetByID(ctx context.Context, id int) (*Player, error)
 	return &player, nil
 }
 
+// GetByName looks a character up by its exact name, ignoring case.
+func (r *PlayerRepository) GetByName(ctx context.Context, name string) (*Player, error) {
+	var player Player
+	query := `
+		SELECT id, name, group_id, account_id, level, vocation, health, healthmax,
+		       experience, lookbody, lookfeet, lookhead, looklegs, looktype, lookaddons,
+		       maglevel, mana, manamax, soul, town_id, posx, posy, posz, cap, sex,
+		       lastlogin, balance, deletion
+		FROM players
+		WHERE name = ?
+	`
+
+	if err := r.db.GetContext(ctx, &player, query, strings.TrimSpace(name)); err != nil {
+		return nil, fmt.Errorf("failed to get player: %w", err)
+	}
+
+	return &player, nil
+}
+
+// IsOnline reports whether a character is logged in to the game server.
+func (r *PlayerRepository) IsOnline(ctx context.Context, playerID int) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM players_online WHERE player_id = ?`
+	if err := r.db.GetContext(ctx, &count, query, playerID); err != nil {
+		return false, fmt.Errorf("failed to get online status: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// CountOnline returns how many characters are logged in to the game server.
+func (r *PlayerRepository) CountOnline(ctx context.Context) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM players_online`
+	if err := r.db.GetContext(ctx, &count, query); err != nil {
+		return 0, fmt.Errorf("failed to count online players: %w", err)
+	}
+
+	return count, nil
+}
+
 func (r *PlayerRepository) GetByAccountID(ctx context.Context, accountID int) ([]*Player, error) {
 	var players []*Player
 	query := `
 		SELECT id, name, group_id, account_id, level, vocation, health, healthmax,
 		       experience, lookbody, lookfeet, lookhead, looklegs, looktype, lookaddons,
 		       maglevel, mana, manamax, soul, town_id, posx, posy, posz, cap, sex,
-		       lastlogin, balance
+		       lastlogin, balance, deletion
 		FROM players
 		WHERE account_id = ?
 	`
@@ -88,20 +142,108 @@ func (r *PlayerRepository) GetByAccountID(ctx context.Context, accountID int) ([
 	return players, nil
 }
 
-func (r *PlayerRepository) Create(ctx context.Context, input CreatePlayerInput) (*Player, error) {
+// GetByStorage returns the players whose value for a storage key compares to
+// value with op, e.g. everyone with a quest storage at its final stage. At
+// most limit players are returned, and never more than MaxPlayersByStorage.
+func (r *PlayerRepository) GetByStorage(ctx context.Context, key, value int, op StorageComparison, limit int) ([]*Player, error) {
+	operator, ok := storageOperators[op]
+	if !ok {
+		return nil, fmt.Errorf("invalid storage comparison %q", op)
+	}
+
+	var players []*Player
 	query := `
-		INSERT INTO players (name, account_id, sex, vocation)
-		VALUES (?, ?, ?, ?)
+		SELECT p.id, p.name, p.group_id, p.account_id, p.level, p.vocation, p.health, p.healthmax,
+		       p.experience, p.lookbody, p.lookfeet, p.lookhead, p.looklegs, p.looktype, p.lookaddons,
+		       p.maglevel, p.mana, p.manamax, p.soul, p.town_id, p.posx, p.posy, p.posz, p.cap, p.sex,
+		       p.lastlogin, p.balance, p.deletion
+		FROM players p
+		INNER JOIN player_storage s ON s.player_id = p.id
+		WHERE s.` + "`key`" + ` = ? AND s.value ` + operator + ` ?
+		ORDER BY p.name
+		LIMIT ?
 	`
 
-	result, err := r.db.ExecContext(ctx, query, input.Name, input.AccountID, input.Sex, input.Vocation)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create player: %w", err)
+	limit = min(max(limit, 0), MaxPlayersByStorage)
+	if err := r.db.SelectContext(ctx, &players, query, key, value, limit); err != nil {
+		return nil, fmt.Errorf("failed to get players by storage: %w", err)
+	}
+
+	return players, nil
+}
+
+// Create inserts a new character using the template for its vocation. The
+// character starts at the temple of the template's town, and the insert is
+// refused once the account already has MaxPerAccount characters.
+func (r *PlayerRepository) Create(ctx context.Context, input CreatePlayerInput) (*Player, error) {
+	template, ok := r.creation.Templates[input.Vocation]
+	if !ok {
+		return nil, fmt.Errorf("no character template for vocation %d", input.Vocation)
+	}
+	if input.Sex != SexFemale && input.Sex != SexMale {
+		return nil, fmt.Errorf("invalid sex %d", input.Sex)
 	}
 
-	id, err := result.LastInsertId()
+	var id int64
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		// Lock the account so concurrent creations can't exceed the limit
+		var accountID int
+		query := `SELECT id FROM accounts WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &accountID, query, input.AccountID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("account %d not found", input.AccountID)
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+
+		var count int
+		query = `SELECT COUNT(*) FROM players WHERE account_id = ?`
+		if err := tx.GetContext(ctx, &count, query, input.AccountID); err != nil {
+			return fmt.Errorf("failed to count players: %w", err)
+		}
+		if count >= r.creation.MaxPerAccount {
+			return ErrCharacterLimit
+		}
+
+		var town Town
+		query = `SELECT id, name, posx, posy, posz FROM towns WHERE id = ?`
+		if err := tx.GetContext(ctx, &town, query, template.TownID); err != nil {
+			return fmt.Errorf("failed to get town %d: %w", template.TownID, err)
+		}
+
+		query = `
+			INSERT INTO players (name, account_id, sex, vocation, level, experience,
+			                     health, healthmax, mana, manamax, cap, soul,
+			                     looktype, lookhead, lookbody, looklegs, lookfeet,
+			                     town_id, posx, posy, posz, maglevel,
+			                     skill_fist, skill_club, skill_sword, skill_axe,
+			                     skill_dist, skill_shielding, skill_fishing, conditions)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '')
+		`
+
+		result, err := tx.ExecContext(ctx, query,
+			input.Name, input.AccountID, input.Sex, input.Vocation,
+			template.Level, ExperienceForLevel(template.Level),
+			template.Health, template.Health, template.Mana, template.Mana, template.Cap, template.Soul,
+			template.Outfit.LookType(input.Sex), template.Outfit.Head, template.Outfit.Body,
+			template.Outfit.Legs, template.Outfit.Feet,
+			town.ID, town.PosX, town.PosY, town.PosZ, template.Skills.Magic,
+			template.Skills.Fist, template.Skills.Club, template.Skills.Sword, template.Skills.Axe,
+			template.Skills.Distance, template.Skills.Shielding, template.Skills.Fishing,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create player: %w", err)
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		return nil, err
 	}
 
 	return r.GetByID(ctx, int(id))
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PlayerNameChange struct {
+	PlayerID  int    `db:"player_id" json:"playerId"`
+	OldName   string `db:"old_name" json:"oldName"`
+	NewName   string `db:"new_name" json:"newName"`
+	ChangedAt int64  `db:"changed_at" json:"changedAt"`
+}
+
+// GetNameHistory returns the player's former names, most recent first.
+func (r *PlayerRepository) GetNameHistory(ctx context.Context, playerID int) ([]*PlayerNameChange, error) {
+	var history []*PlayerNameChange
+	query := `SELECT player_id, old_name, new_name, changed_at FROM api_player_name_history
+	          WHERE player_id = ? ORDER BY changed_at DESC`
+
+	if err := r.db.SelectContext(ctx, &history, query, playerID); err != nil {
+		return nil, fmt.Errorf("failed to get name history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ChangeName renames a player and records the old name in the name history.
+// The name format is expected to have been checked by a NameValidator.
+func (r *PlayerRepository) ChangeName(ctx context.Context, playerID int, newName string) (*Player, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		var count int
+		query := `SELECT COUNT(*) FROM players WHERE LOWER(name) = LOWER(?) AND id <> ?`
+		if err := tx.GetContext(ctx, &count, query, newName, playerID); err != nil {
+			return fmt.Errorf("failed to check name: %w", err)
+		}
+		if count > 0 {
+			return ErrNameTaken
+		}
+
+		return renamePlayer(ctx, tx, playerID, newName)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, playerID)
+}
+
+// ChangeSex switches a player to the other sex, swapping its look type for
+// the same outfit of the new sex.
+func (r *PlayerRepository) ChangeSex(ctx context.Context, playerID int) (*Player, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		var look struct {
+			Sex      int `db:"sex"`
+			LookType int `db:"looktype"`
+		}
+		query := `SELECT sex, looktype FROM players WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &look, query, playerID); err != nil {
+			return fmt.Errorf("failed to get player: %w", err)
+		}
+
+		sex := SexMale
+		if look.Sex == SexMale {
+			sex = SexFemale
+		}
+
+		query = `UPDATE players SET sex = ?, looktype = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, sex, OutfitForSex(look.LookType, sex), playerID); err != nil {
+			return fmt.Errorf("failed to change player sex: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, playerID)
+}
+
+// ChangeTown moves a player's residence to townID and places it at that
+// town's temple.
+func (r *PlayerRepository) ChangeTown(ctx context.Context, playerID, townID int) (*Player, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		var town Town
+		query := `SELECT id, name, posx, posy, posz FROM towns WHERE id = ?`
+		if err := tx.GetContext(ctx, &town, query, townID); err != nil {
+			return fmt.Errorf("failed to get town %d: %w", townID, err)
+		}
+
+		query = `UPDATE players SET town_id = ?, posx = ?, posy = ?, posz = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, town.ID, town.PosX, town.PosY, town.PosZ, playerID); err != nil {
+			return fmt.Errorf("failed to change player town: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, playerID)
+}
+
+// renamePlayer sets a player's name and keeps the old one in the history.
+func renamePlayer(ctx context.Context, tx *sqlx.Tx, playerID int, newName string) error {
+	var oldName string
+	query := `SELECT name FROM players WHERE id = ? FOR UPDATE`
+	if err := tx.GetContext(ctx, &oldName, query, playerID); err != nil {
+		return fmt.Errorf("failed to get player: %w", err)
+	}
+
+	query = `UPDATE players SET name = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, newName, playerID); err != nil {
+		return fmt.Errorf("failed to rename player: %w", err)
+	}
+
+	query = `INSERT INTO api_player_name_history (player_id, old_name, new_name, changed_at)
+	         VALUES (?, ?, ?, UNIX_TIMESTAMP())`
+	if _, err := tx.ExecContext(ctx, query, playerID, oldName, newName); err != nil {
+		return fmt.Errorf("failed to record name change: %w", err)
+	}
+
+	return nil
+}
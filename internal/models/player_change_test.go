@@ -0,0 +1,138 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectGetPlayer(mock sqlmock.Sqlmock, playerID int) {
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(playerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(playerID, "Player"))
+}
+
+func TestOutfitForSex(t *testing.T) {
+	assert.Equal(t, 128, OutfitForSex(136, SexMale))
+	assert.Equal(t, 136, OutfitForSex(128, SexFemale))
+	assert.Equal(t, 273, OutfitForSex(270, SexMale))
+	assert.Equal(t, 128, OutfitForSex(128, SexMale))
+	assert.Equal(t, 136, OutfitForSex(9999, SexFemale))
+}
+
+func TestPlayerRepository_ChangeName(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players WHERE LOWER\\(name\\) = LOWER\\(\\?\\) AND id <> \\?").
+		WithArgs("New Name", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT name FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Old Name"))
+	mock.ExpectExec("UPDATE players SET name = ?").
+		WithArgs("New Name", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO api_player_name_history").
+		WithArgs(1, "Old Name", "New Name").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	expectGetPlayer(mock, 1)
+
+	player, err := repo.ChangeName(context.Background(), 1, "New Name")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, player.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ChangeName_Online(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, true)
+	mock.ExpectRollback()
+
+	player, err := repo.ChangeName(context.Background(), 1, "New Name")
+
+	assert.ErrorIs(t, err, ErrPlayerOnline)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ChangeSex(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
+	mock.ExpectQuery("SELECT sex, looktype FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"sex", "looktype"}).AddRow(SexMale, 131))
+	mock.ExpectExec("UPDATE players SET sex = \\?, looktype = \\? WHERE id = \\?").
+		WithArgs(SexFemale, 139, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectGetPlayer(mock, 1)
+
+	_, err := repo.ChangeSex(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ChangeTown(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
+	mock.ExpectQuery("SELECT id, name, posx, posy, posz FROM towns WHERE id = ?").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "posx", "posy", "posz"}).AddRow(2, "Carlin", 32360, 31782, 7))
+	mock.ExpectExec("UPDATE players SET town_id = \\?, posx = \\?, posy = \\?, posz = \\? WHERE id = \\?").
+		WithArgs(2, 32360, 31782, 7, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectGetPlayer(mock, 1)
+
+	_, err := repo.ChangeTown(context.Background(), 1, 2)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_GetNameHistory(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	rows := sqlmock.NewRows([]string{"player_id", "old_name", "new_name", "changed_at"}).
+		AddRow(1, "Old Name", "New Name", 1234567890)
+
+	mock.ExpectQuery("SELECT player_id, old_name, new_name, changed_at FROM api_player_name_history").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	history, err := repo.GetNameHistory(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, "Old Name", history[0].OldName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
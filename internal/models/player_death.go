@@ -36,3 +36,16 @@ func (r *PlayerDeathRepository) GetByPlayerID(ctx context.Context, playerID int)
 
 	return deaths, nil
 }
+
+// GetRecent returns a player's latest deaths, newest first.
+func (r *PlayerDeathRepository) GetRecent(ctx context.Context, playerID, limit int) ([]*PlayerDeath, error) {
+	var deaths []*PlayerDeath
+	query := `SELECT player_id, time, level, killed_by, is_player, mostdamage_by, mostdamage_is_player
+	          FROM player_deaths WHERE player_id = ? ORDER BY time DESC LIMIT ?`
+
+	if err := r.db.SelectContext(ctx, &deaths, query, playerID, limit); err != nil {
+		return nil, fmt.Errorf("failed to get player deaths: %w", err)
+	}
+
+	return deaths, nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrGuildLeader        = errors.New("player leads a guild; pass leadership or disband it first")
+	ErrHouseOwner         = errors.New("player owns a house; leave or transfer it first")
+	ErrDeletionNotPending = errors.New("player is not scheduled for deletion")
+	ErrDeletionAlreadySet = errors.New("player is already scheduled for deletion")
+)
+
+// playerCleanupQueries remove everything that references a player before the
+// player row itself is deleted.
+var playerCleanupQueries = []string{
+	`DELETE FROM player_items WHERE player_id = ?`,
+	`DELETE FROM player_depotitems WHERE player_id = ?`,
+	`DELETE FROM player_inboxitems WHERE player_id = ?`,
+	`DELETE FROM player_storeinboxitems WHERE player_id = ?`,
+	`DELETE FROM player_storage WHERE player_id = ?`,
+	`DELETE FROM player_spells WHERE player_id = ?`,
+	`DELETE FROM player_namelocks WHERE player_id = ?`,
+	`DELETE FROM guild_membership WHERE player_id = ?`,
+	`DELETE FROM guild_invites WHERE player_id = ?`,
+	`DELETE FROM account_viplist WHERE player_id = ?`,
+	`DELETE FROM market_offers WHERE player_id = ?`,
+	`UPDATE houses SET bid = 0, bid_end = 0, last_bid = 0, highest_bidder = 0 WHERE highest_bidder = ?`,
+}
+
+// ScheduleDeletion marks a player for deletion once grace has passed, by
+// setting TFS's players.deletion timestamp. Online players, guild leaders
+// and house owners are refused until they resolve those first.
+func (r *PlayerRepository) ScheduleDeletion(ctx context.Context, playerID int, grace time.Duration) (*Player, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var deletion int64
+		query := `SELECT deletion FROM players WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &deletion, query, playerID); err != nil {
+			return fmt.Errorf("failed to get player: %w", err)
+		}
+		if deletion != 0 {
+			return ErrDeletionAlreadySet
+		}
+
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+		if err := checkDeletable(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query = `UPDATE players SET deletion = UNIX_TIMESTAMP() + ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, int64(grace.Seconds()), playerID); err != nil {
+			return fmt.Errorf("failed to schedule player deletion: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, playerID)
+}
+
+// CancelDeletion restores a player scheduled for deletion.
+func (r *PlayerRepository) CancelDeletion(ctx context.Context, playerID int) (*Player, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := `UPDATE players SET deletion = 0 WHERE id = ? AND deletion <> 0`
+		result, err := tx.ExecContext(ctx, query, playerID)
+		if err != nil {
+			return fmt.Errorf("failed to cancel player deletion: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		if affected == 0 {
+			return ErrDeletionNotPending
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, playerID)
+}
+
+// DeleteExpired permanently removes every player whose deletion time has
+// passed, along with its items, storage, guild membership, house bids and
+// VIP entries. Players whose deletion was cancelled, that are online or that
+// became a guild leader or house owner in the meantime are skipped. It
+// returns the number of players removed.
+func (r *PlayerRepository) DeleteExpired(ctx context.Context) (int, error) {
+	var playerIDs []int
+	query := `SELECT id FROM players WHERE deletion <> 0 AND deletion <= UNIX_TIMESTAMP()`
+	if err := r.db.SelectContext(ctx, &playerIDs, query); err != nil {
+		return 0, fmt.Errorf("failed to get expired players: %w", err)
+	}
+
+	deleted := 0
+	for _, playerID := range playerIDs {
+		err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+			// The list above was read outside this transaction, so the
+			// deletion may have been cancelled since
+			var deletion int64
+			query := `SELECT deletion FROM players WHERE id = ? FOR UPDATE`
+			if err := tx.GetContext(ctx, &deletion, query, playerID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrDeletionNotPending
+				}
+				return fmt.Errorf("failed to get player: %w", err)
+			}
+			if deletion == 0 || deletion > time.Now().Unix() {
+				return ErrDeletionNotPending
+			}
+
+			if err := guardOffline(ctx, tx, playerID); err != nil {
+				return err
+			}
+			if err := checkDeletable(ctx, tx, playerID); err != nil {
+				return err
+			}
+			return deletePlayer(ctx, tx, playerID)
+		})
+		if errors.Is(err, ErrDeletionNotPending) || errors.Is(err, ErrPlayerOnline) ||
+			errors.Is(err, ErrGuildLeader) || errors.Is(err, ErrHouseOwner) {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func checkDeletable(ctx context.Context, tx *sqlx.Tx, playerID int) error {
+	var count int
+	query := `SELECT COUNT(*) FROM guilds WHERE ownerid = ?`
+	if err := tx.GetContext(ctx, &count, query, playerID); err != nil {
+		return fmt.Errorf("failed to check guild ownership: %w", err)
+	}
+	if count > 0 {
+		return ErrGuildLeader
+	}
+
+	query = `SELECT COUNT(*) FROM houses WHERE owner = ?`
+	if err := tx.GetContext(ctx, &count, query, playerID); err != nil {
+		return fmt.Errorf("failed to check house ownership: %w", err)
+	}
+	if count > 0 {
+		return ErrHouseOwner
+	}
+
+	return nil
+}
+
+func deletePlayer(ctx context.Context, tx *sqlx.Tx, playerID int) error {
+	for _, query := range playerCleanupQueries {
+		if _, err := tx.ExecContext(ctx, query, playerID); err != nil {
+			return fmt.Errorf("failed to clean up player %d: %w", playerID, err)
+		}
+	}
+
+	query := `DELETE FROM players WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, playerID); err != nil {
+		return fmt.Errorf("failed to delete player %d: %w", playerID, err)
+	}
+
+	return nil
+}
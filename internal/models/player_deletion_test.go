@@ -0,0 +1,187 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectDeletableChecks(mock sqlmock.Sqlmock, playerID, guilds, houses int) {
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM guilds WHERE ownerid = ?").
+		WithArgs(playerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(guilds))
+	if guilds > 0 {
+		return
+	}
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM houses WHERE owner = ?").
+		WithArgs(playerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(houses))
+}
+
+func TestPlayerRepository_ScheduleDeletion(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT deletion FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"deletion"}).AddRow(0))
+	expectOnlineCheck(mock, 1, false)
+	expectDeletableChecks(mock, 1, 0, 0)
+	mock.ExpectExec("UPDATE players SET deletion = UNIX_TIMESTAMP\\(\\) \\+ \\? WHERE id = \\?").
+		WithArgs(int64(86400), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deletion"}).AddRow(1, "Player", 1234654290))
+
+	player, err := repo.ScheduleDeletion(context.Background(), 1, 24*time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1234654290), player.Deletion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ScheduleDeletion_GuildLeader(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT deletion FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"deletion"}).AddRow(0))
+	expectOnlineCheck(mock, 1, false)
+	expectDeletableChecks(mock, 1, 1, 0)
+	mock.ExpectRollback()
+
+	player, err := repo.ScheduleDeletion(context.Background(), 1, time.Hour)
+
+	assert.ErrorIs(t, err, ErrGuildLeader)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ScheduleDeletion_HouseOwner(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT deletion FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"deletion"}).AddRow(0))
+	expectOnlineCheck(mock, 1, false)
+	expectDeletableChecks(mock, 1, 0, 1)
+	mock.ExpectRollback()
+
+	player, err := repo.ScheduleDeletion(context.Background(), 1, time.Hour)
+
+	assert.ErrorIs(t, err, ErrHouseOwner)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_ScheduleDeletion_Online(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT deletion FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"deletion"}).AddRow(0))
+	expectOnlineCheck(mock, 1, true)
+	mock.ExpectRollback()
+
+	player, err := repo.ScheduleDeletion(context.Background(), 1, time.Hour)
+
+	assert.ErrorIs(t, err, ErrPlayerOnline)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_CancelDeletion_NotPending(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
+	mock.ExpectExec("UPDATE players SET deletion = 0 WHERE id = \\? AND deletion <> 0").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	player, err := repo.CancelDeletion(context.Background(), 1)
+
+	assert.ErrorIs(t, err, ErrDeletionNotPending)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectDeletionDue(mock sqlmock.Sqlmock, playerID int, deletion int64) {
+	mock.ExpectQuery("SELECT deletion FROM players WHERE id = \\? FOR UPDATE").
+		WithArgs(playerID).
+		WillReturnRows(sqlmock.NewRows([]string{"deletion"}).AddRow(deletion))
+}
+
+func TestPlayerRepository_DeleteExpired(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	mock.ExpectQuery("SELECT id FROM players WHERE deletion <> 0 AND deletion <= UNIX_TIMESTAMP\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4))
+
+	// Player 1 is removed with everything that references it
+	mock.ExpectBegin()
+	expectDeletionDue(mock, 1, 1)
+	expectOnlineCheck(mock, 1, false)
+	expectDeletableChecks(mock, 1, 0, 0)
+	for range playerCleanupQueries {
+		mock.ExpectExec("(DELETE FROM|UPDATE) (.+)").
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("DELETE FROM players WHERE id = ?").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Player 2 bought a house in the meantime and is skipped
+	mock.ExpectBegin()
+	expectDeletionDue(mock, 2, 1)
+	expectOnlineCheck(mock, 2, false)
+	expectDeletableChecks(mock, 2, 0, 1)
+	mock.ExpectRollback()
+
+	// Player 3 is online and is skipped until it logs out
+	mock.ExpectBegin()
+	expectDeletionDue(mock, 3, 1)
+	expectOnlineCheck(mock, 3, true)
+	mock.ExpectRollback()
+
+	// Player 4's deletion was cancelled after the list was read
+	mock.ExpectBegin()
+	expectDeletionDue(mock, 4, 0)
+	mock.ExpectRollback()
+
+	deleted, err := repo.DeleteExpired(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TFS keeps a player's tamed mounts as bit flags in the player_storage keys
+// from MountStorageStart on: mount id is bit (id-1)%31 of key
+// MountStorageStart + (id-1)/31. Mount ids are a single byte in TFS.
+const (
+	MountStorageStart = 10002001
+	MountStorageSize  = 10
+	mountsPerKey      = 31
+	maxMountID        = 255
+)
+
+var ErrInvalidMount = fmt.Errorf("mount id must be between 1 and %d", maxMountID)
+
+// Mount is a mount defined in the server's data/XML/mounts.xml.
+type Mount struct {
+	ID       int    `json:"id"`
+	ClientID int    `json:"clientId"`
+	Name     string `json:"name"`
+	Speed    int    `json:"speed"`
+	Premium  bool   `json:"premium"`
+}
+
+// MountCatalog holds the mounts defined by the game server.
+type MountCatalog struct {
+	Mounts []*Mount
+}
+
+type mountsXML struct {
+	Mounts []struct {
+		ID       int    `xml:"id,attr"`
+		ClientID int    `xml:"clientid,attr"`
+		Name     string `xml:"name,attr"`
+		Speed    int    `xml:"speed,attr"`
+		Premium  string `xml:"premium,attr"`
+	} `xml:"mount"`
+}
+
+// LoadMountCatalog reads a TFS mounts.xml file.
+func LoadMountCatalog(path string) (*MountCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mounts: %w", err)
+	}
+
+	var doc mountsXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse mounts: %w", err)
+	}
+
+	catalog := &MountCatalog{}
+	for _, m := range doc.Mounts {
+		catalog.Mounts = append(catalog.Mounts, &Mount{
+			ID:       m.ID,
+			ClientID: m.ClientID,
+			Name:     m.Name,
+			Speed:    m.Speed,
+			Premium:  xmlBool(m.Premium),
+		})
+	}
+
+	return catalog, nil
+}
+
+// PlayerMounts returns the mounts a player has tamed, in id order. Mounts
+// missing from the catalog are returned with only their id.
+func (c *MountCatalog) PlayerMounts(storage []*PlayerStorage) []*Mount {
+	byID := make(map[int]*Mount, len(c.Mounts))
+	for _, mount := range c.Mounts {
+		byID[mount.ID] = mount
+	}
+
+	mounts := []*Mount{}
+	for _, id := range DecodeMounts(storage) {
+		mount, ok := byID[id]
+		if !ok {
+			mount = &Mount{ID: id}
+		}
+		mounts = append(mounts, mount)
+	}
+
+	return mounts
+}
+
+// DecodeMounts returns the ids of the mounts tamed in storage, sorted.
+// Storage outside the mount range is ignored.
+func DecodeMounts(storage []*PlayerStorage) []int {
+	var ids []int
+	for _, s := range storage {
+		if s.Key < MountStorageStart || s.Key >= MountStorageStart+MountStorageSize {
+			continue
+		}
+		for bit := 0; bit < mountsPerKey; bit++ {
+			if s.Value&(1<<bit) != 0 {
+				ids = append(ids, (s.Key-MountStorageStart)*mountsPerKey+bit+1)
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// mountStorage returns the storage key and bit of a mount.
+func mountStorage(mountID int) (int, int, error) {
+	if mountID < 1 || mountID > maxMountID {
+		return 0, 0, ErrInvalidMount
+	}
+	return MountStorageStart + (mountID-1)/mountsPerKey, 1 << ((mountID - 1) % mountsPerKey), nil
+}
+
+// GrantMount marks a mount as tamed for a player.
+func (r *PlayerStorageRepository) GrantMount(ctx context.Context, playerID, mountID int) error {
+	key, bit, err := mountStorage(mountID)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := "INSERT INTO player_storage (player_id, `key`, value) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value = value | VALUES(value)"
+		if _, err := tx.ExecContext(ctx, query, playerID, key, bit); err != nil {
+			return fmt.Errorf("failed to grant mount: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RevokeMount clears a mount's tamed flag. It reports whether the player had
+// the mount.
+func (r *PlayerStorageRepository) RevokeMount(ctx context.Context, playerID, mountID int) (bool, error) {
+	key, bit, err := mountStorage(mountID)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		var value int
+		query := "SELECT value FROM player_storage WHERE player_id = ? AND `key` = ? FOR UPDATE"
+		if err := tx.GetContext(ctx, &value, query, playerID, key); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("failed to get mount storage: %w", err)
+		}
+		if value&bit == 0 {
+			return nil
+		}
+		found = true
+
+		query = "UPDATE player_storage SET value = ? WHERE player_id = ? AND `key` = ?"
+		if _, err := tx.ExecContext(ctx, query, value&^bit, playerID, key); err != nil {
+			return fmt.Errorf("failed to revoke mount: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
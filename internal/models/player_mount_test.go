@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMountCatalog(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<mounts>
+	<mount id="1" clientid="368" name="Widow Queen" speed="20" premium="yes" />
+	<mount id="2" clientid="369" name="Racing Bird" speed="20" premium="no" />
+</mounts>`
+	path := filepath.Join(t.TempDir(), "mounts.xml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	catalog, err := LoadMountCatalog(path)
+
+	require.NoError(t, err)
+	require.Len(t, catalog.Mounts, 2)
+	assert.Equal(t, 368, catalog.Mounts[0].ClientID)
+	assert.True(t, catalog.Mounts[0].Premium)
+	assert.False(t, catalog.Mounts[1].Premium)
+}
+
+func TestDecodeMounts(t *testing.T) {
+	storage := []*PlayerStorage{
+		{Key: MountStorageStart, Value: 1<<0 | 1<<2},
+		{Key: MountStorageStart + 1, Value: 1 << 0},
+		{Key: MountStorageStart + MountStorageSize, Value: 5},
+		{Key: 50000, Value: 1},
+	}
+
+	assert.Equal(t, []int{1, 3, 32}, DecodeMounts(storage))
+}
+
+func TestMountCatalog_PlayerMounts(t *testing.T) {
+	catalog := &MountCatalog{Mounts: []*Mount{{ID: 1, Name: "Widow Queen"}}}
+
+	mounts := catalog.PlayerMounts([]*PlayerStorage{{Key: MountStorageStart, Value: 1<<0 | 1<<1}})
+
+	require.Len(t, mounts, 2)
+	assert.Equal(t, "Widow Queen", mounts[0].Name)
+	assert.Equal(t, 2, mounts[1].ID)
+	assert.Empty(t, mounts[1].Name)
+}
+
+func TestPlayerStorageRepository_GrantMount(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("Granted", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		mock.ExpectExec("INSERT INTO player_storage (.+) ON DUPLICATE KEY UPDATE value = value \\| VALUES\\(value\\)").
+			WithArgs(1, MountStorageStart+1, 1<<1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.GrantMount(context.Background(), 1, 33)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidMount", func(t *testing.T) {
+		assert.ErrorIs(t, repo.GrantMount(context.Background(), 1, 0), ErrInvalidMount)
+		assert.ErrorIs(t, repo.GrantMount(context.Background(), 1, 256), ErrInvalidMount)
+	})
+}
+
+func TestPlayerStorageRepository_RevokeMount(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("Revoked", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		mock.ExpectQuery("SELECT value FROM player_storage WHERE player_id = \\? AND `key` = \\? FOR UPDATE").
+			WithArgs(1, MountStorageStart).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(1<<0 | 1<<2))
+		mock.ExpectExec("UPDATE player_storage SET value = \\?").
+			WithArgs(1<<0, 1, MountStorageStart).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeMount(context.Background(), 1, 3)
+
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotTamed", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		mock.ExpectQuery("SELECT value FROM player_storage").
+			WithArgs(1, MountStorageStart).
+			WillReturnRows(sqlmock.NewRows([]string{"value"}))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeMount(context.Background(), 1, 3)
+
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
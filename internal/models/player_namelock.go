@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNotNamelocked = errors.New("player is not namelocked")
+
+type PlayerNamelock struct {
+	PlayerID     int    `db:"player_id" json:"playerId"`
+	Reason       string `db:"reason" json:"reason"`
+	NamelockedAt int64  `db:"namelocked_at" json:"namelockedAt"`
+	NamelockedBy int    `db:"namelocked_by" json:"namelockedBy"`
+}
+
+type PlayerNamelockRepository struct {
+	db *database.DB
+}
+
+func NewPlayerNamelockRepository(db *database.DB) *PlayerNamelockRepository {
+	return &PlayerNamelockRepository{db: db}
+}
+
+func (r *PlayerNamelockRepository) GetByPlayerID(ctx context.Context, playerID int) (*PlayerNamelock, error) {
+	var namelock PlayerNamelock
+	query := `SELECT player_id, reason, namelocked_at, namelocked_by FROM player_namelocks WHERE player_id = ?`
+
+	if err := r.db.GetContext(ctx, &namelock, query, playerID); err != nil {
+		return nil, fmt.Errorf("failed to get player namelock: %w", err)
+	}
+
+	return &namelock, nil
+}
+
+func (r *PlayerNamelockRepository) Create(ctx context.Context, playerID int, reason string, namelockedBy int) (*PlayerNamelock, error) {
+	query := `INSERT INTO player_namelocks (player_id, reason, namelocked_at, namelocked_by)
+	          VALUES (?, ?, UNIX_TIMESTAMP(), ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, playerID, reason, namelockedBy); err != nil {
+		return nil, fmt.Errorf("failed to create player namelock: %w", err)
+	}
+
+	return r.GetByPlayerID(ctx, playerID)
+}
+
+// Resolve renames a namelocked player and lifts the namelock in a single
+// transaction, so the player can log in again under the new name. The name
+// format is expected to have been checked by a NameValidator already.
+func (r *PlayerNamelockRepository) Resolve(ctx context.Context, playerID int, newName string) error {
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		var lockedID int
+		query := `SELECT player_id FROM player_namelocks WHERE player_id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &lockedID, query, playerID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotNamelocked
+			}
+			return fmt.Errorf("failed to get player namelock: %w", err)
+		}
+
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		var count int
+		query = `SELECT COUNT(*) FROM players WHERE LOWER(name) = LOWER(?) AND id <> ?`
+		if err := tx.GetContext(ctx, &count, query, newName, playerID); err != nil {
+			return fmt.Errorf("failed to check name: %w", err)
+		}
+		if count > 0 {
+			return ErrNameTaken
+		}
+
+		if err := renamePlayer(ctx, tx, playerID, newName); err != nil {
+			return err
+		}
+
+		query = `DELETE FROM player_namelocks WHERE player_id = ?`
+		if _, err := tx.ExecContext(ctx, query, playerID); err != nil {
+			return fmt.Errorf("failed to remove player namelock: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerNamelockRepository_Create(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPlayerNamelockRepository(db)
+
+	mock.ExpectExec("INSERT INTO player_namelocks").
+		WithArgs(5, "Offensive name", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rows := sqlmock.NewRows([]string{"player_id", "reason", "namelocked_at", "namelocked_by"}).
+		AddRow(5, "Offensive name", 1234567890, 1)
+
+	mock.ExpectQuery("SELECT player_id, reason, namelocked_at, namelocked_by FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnRows(rows)
+
+	namelock, err := repo.Create(context.Background(), 5, "Offensive name", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, namelock.PlayerID)
+	assert.Equal(t, "Offensive name", namelock.Reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerNamelockRepository_Resolve(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPlayerNamelockRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT player_id FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id"}).AddRow(5))
+	expectOnlineCheck(mock, 5, false)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players WHERE LOWER\\(name\\) = LOWER\\(\\?\\)").
+		WithArgs("Sir Lancelot", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT name FROM players WHERE id = ?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Offensive Name"))
+	mock.ExpectExec("UPDATE players SET name = ?").
+		WithArgs("Sir Lancelot", 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO api_player_name_history").
+		WithArgs(5, "Offensive Name", "Sir Lancelot").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("DELETE FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Resolve(context.Background(), 5, "Sir Lancelot")
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerNamelockRepository_Resolve_NotNamelocked(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPlayerNamelockRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT player_id FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	err = repo.Resolve(context.Background(), 5, "Sir Lancelot")
+
+	assert.ErrorIs(t, err, ErrNotNamelocked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerNamelockRepository_Resolve_NameTaken(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPlayerNamelockRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT player_id FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id"}).AddRow(5))
+	expectOnlineCheck(mock, 5, false)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players").
+		WithArgs("Sir Lancelot", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = repo.Resolve(context.Background(), 5, "Sir Lancelot")
+
+	assert.ErrorIs(t, err, ErrNameTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerNamelockRepository_Resolve_Online(t *testing.T) {
+	db, mock, err := NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPlayerNamelockRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT player_id FROM player_namelocks WHERE player_id = ?").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"player_id"}).AddRow(5))
+	expectOnlineCheck(mock, 5, true)
+	mock.ExpectRollback()
+
+	err = repo.Resolve(context.Background(), 5, "Sir Lancelot")
+
+	assert.ErrorIs(t, err, ErrPlayerOnline)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TFS keeps a player's unlocked outfits in the player_storage keys from
+// OutfitStorageStart to OutfitStorageStart+OutfitStorageSize, one outfit per
+// key, with the value lookType<<16 | addons. It writes them from
+// OutfitStorageStart+1 on.
+const (
+	OutfitStorageStart = 10001000
+	OutfitStorageSize  = 500
+)
+
+var ErrOutfitStorageFull = errors.New("player has no free outfit storage keys")
+
+// Outfit is an outfit defined in the server's data/XML/outfits.xml.
+type Outfit struct {
+	Sex      int    `json:"sex"`
+	LookType int    `json:"lookType"`
+	Name     string `json:"name"`
+	Premium  bool   `json:"premium"`
+	Unlocked bool   `json:"unlocked"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// PlayerOutfit is an outfit a player can wear, with the addons it unlocked.
+type PlayerOutfit struct {
+	LookType int    `json:"lookType"`
+	Name     string `json:"name"`
+	Premium  bool   `json:"premium"`
+	Addons   int    `json:"addons"`
+}
+
+func (o *PlayerOutfit) FirstAddon() bool {
+	return o.Addons&1 != 0
+}
+
+func (o *PlayerOutfit) SecondAddon() bool {
+	return o.Addons&2 != 0
+}
+
+// OutfitCatalog holds the outfits defined by the game server.
+type OutfitCatalog struct {
+	Outfits []*Outfit
+}
+
+type outfitsXML struct {
+	Outfits []struct {
+		Type     int    `xml:"type,attr"`
+		LookType int    `xml:"looktype,attr"`
+		Name     string `xml:"name,attr"`
+		Premium  string `xml:"premium,attr"`
+		Unlocked string `xml:"unlocked,attr"`
+		Enabled  string `xml:"enabled,attr"`
+	} `xml:"outfit"`
+}
+
+// LoadOutfitCatalog reads a TFS outfits.xml file. Like TFS, outfits are
+// unlocked and enabled unless the file says otherwise.
+func LoadOutfitCatalog(path string) (*OutfitCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outfits: %w", err)
+	}
+
+	var doc outfitsXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse outfits: %w", err)
+	}
+
+	catalog := &OutfitCatalog{}
+	for _, o := range doc.Outfits {
+		catalog.Outfits = append(catalog.Outfits, &Outfit{
+			Sex:      o.Type,
+			LookType: o.LookType,
+			Name:     o.Name,
+			Premium:  xmlBool(o.Premium),
+			Unlocked: o.Unlocked == "" || xmlBool(o.Unlocked),
+			Enabled:  o.Enabled == "" || xmlBool(o.Enabled),
+		})
+	}
+
+	return catalog, nil
+}
+
+// ForSex returns the enabled outfits for sex, or every enabled outfit when
+// sex is nil.
+func (c *OutfitCatalog) ForSex(sex *int) []*Outfit {
+	outfits := []*Outfit{}
+	for _, outfit := range c.Outfits {
+		if outfit.Enabled && (sex == nil || outfit.Sex == *sex) {
+			outfits = append(outfits, outfit)
+		}
+	}
+	return outfits
+}
+
+// PlayerOutfits lists the outfits a player of sex can wear: the catalog's
+// outfits for that sex that are unlocked by default or through storage,
+// followed by any unlocked look types the catalog doesn't know.
+func (c *OutfitCatalog) PlayerOutfits(sex int, storage []*PlayerStorage) []*PlayerOutfit {
+	unlocked := DecodeOutfits(storage)
+
+	known := make(map[int]bool)
+	outfits := []*PlayerOutfit{}
+	for _, outfit := range c.Outfits {
+		known[outfit.LookType] = true
+		if !outfit.Enabled || outfit.Sex != sex {
+			continue
+		}
+		addons, ok := unlocked[outfit.LookType]
+		if !ok && !outfit.Unlocked {
+			continue
+		}
+		outfits = append(outfits, &PlayerOutfit{
+			LookType: outfit.LookType,
+			Name:     outfit.Name,
+			Premium:  outfit.Premium,
+			Addons:   addons,
+		})
+	}
+
+	for _, s := range storage {
+		lookType := s.Value >> 16
+		if isOutfitKey(s.Key) && !known[lookType] {
+			known[lookType] = true
+			outfits = append(outfits, &PlayerOutfit{LookType: lookType, Addons: unlocked[lookType]})
+		}
+	}
+
+	return outfits
+}
+
+// DecodeOutfits returns the addons of each outfit unlocked in storage, keyed
+// by look type. Storage outside the outfit range is ignored.
+func DecodeOutfits(storage []*PlayerStorage) map[int]int {
+	outfits := make(map[int]int)
+	for _, s := range storage {
+		if isOutfitKey(s.Key) {
+			outfits[s.Value>>16] |= s.Value & 0xFF
+		}
+	}
+	return outfits
+}
+
+func isOutfitKey(key int) bool {
+	return key >= OutfitStorageStart && key <= OutfitStorageStart+OutfitStorageSize
+}
+
+// GrantOutfit unlocks an outfit for a player, adding addons to the ones it
+// already has.
+func (r *PlayerStorageRepository) GrantOutfit(ctx context.Context, playerID, lookType, addons int) error {
+	return r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		storage, err := lockOutfitStorage(ctx, tx, playerID)
+		if err != nil {
+			return err
+		}
+
+		key := 0
+		used := make(map[int]bool, len(storage))
+		for _, s := range storage {
+			used[s.Key] = true
+			if s.Value>>16 == lookType {
+				key = s.Key
+				addons |= s.Value & 0xFF
+			}
+		}
+		for k := OutfitStorageStart + 1; key == 0 && k <= OutfitStorageStart+OutfitStorageSize; k++ {
+			if !used[k] {
+				key = k
+			}
+		}
+		if key == 0 {
+			return ErrOutfitStorageFull
+		}
+
+		query := "INSERT INTO player_storage (player_id, `key`, value) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value = VALUES(value)"
+		if _, err := tx.ExecContext(ctx, query, playerID, key, lookType<<16|addons&0xFF); err != nil {
+			return fmt.Errorf("failed to grant outfit: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RevokeOutfit removes addons from an unlocked outfit, or the whole outfit
+// when addons is nil. It reports whether the player had the outfit.
+func (r *PlayerStorageRepository) RevokeOutfit(ctx context.Context, playerID, lookType int, addons *int) (bool, error) {
+	found := false
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		storage, err := lockOutfitStorage(ctx, tx, playerID)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range storage {
+			if s.Value>>16 != lookType {
+				continue
+			}
+			found = true
+
+			if addons == nil {
+				query := "DELETE FROM player_storage WHERE player_id = ? AND `key` = ?"
+				if _, err := tx.ExecContext(ctx, query, playerID, s.Key); err != nil {
+					return fmt.Errorf("failed to revoke outfit: %w", err)
+				}
+				continue
+			}
+
+			query := "UPDATE player_storage SET value = ? WHERE player_id = ? AND `key` = ?"
+			if _, err := tx.ExecContext(ctx, query, s.Value&^(*addons&0xFF), playerID, s.Key); err != nil {
+				return fmt.Errorf("failed to revoke outfit addons: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return found, nil
+}
+
+func lockOutfitStorage(ctx context.Context, tx *sqlx.Tx, playerID int) ([]*PlayerStorage, error) {
+	var storage []*PlayerStorage
+	query := "SELECT player_id, `key`, value FROM player_storage " +
+		"WHERE player_id = ? AND `key` BETWEEN ? AND ? FOR UPDATE"
+
+	if err := tx.SelectContext(ctx, &storage, query, playerID, OutfitStorageStart, OutfitStorageStart+OutfitStorageSize); err != nil {
+		return nil, fmt.Errorf("failed to get outfit storage: %w", err)
+	}
+
+	return storage, nil
+}
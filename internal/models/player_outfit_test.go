@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOutfitsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<outfits>
+	<outfit type="0" looktype="136" name="Citizen" premium="no" unlocked="yes" enabled="yes" />
+	<outfit type="0" looktype="137" name="Hunter" premium="no" unlocked="yes" enabled="yes" />
+	<outfit type="0" looktype="269" name="Nightmare" premium="yes" unlocked="no" enabled="yes" />
+	<outfit type="1" looktype="128" name="Citizen" premium="no" unlocked="yes" enabled="yes" />
+	<outfit type="1" looktype="268" name="Nightmare" premium="yes" unlocked="no" enabled="yes" />
+	<outfit type="1" looktype="302" name="Old" premium="no" unlocked="yes" enabled="no" />
+</outfits>`
+
+func TestLoadOutfitCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outfits.xml")
+	require.NoError(t, os.WriteFile(path, []byte(testOutfitsXML), 0o600))
+
+	catalog, err := LoadOutfitCatalog(path)
+
+	require.NoError(t, err)
+	require.Len(t, catalog.Outfits, 6)
+	assert.Equal(t, "Nightmare", catalog.Outfits[2].Name)
+	assert.True(t, catalog.Outfits[2].Premium)
+	assert.False(t, catalog.Outfits[2].Unlocked)
+	assert.False(t, catalog.Outfits[5].Enabled)
+
+	male := SexMale
+	assert.Len(t, catalog.ForSex(&male), 2)
+	assert.Len(t, catalog.ForSex(nil), 5)
+}
+
+func TestOutfitCatalog_PlayerOutfits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outfits.xml")
+	require.NoError(t, os.WriteFile(path, []byte(testOutfitsXML), 0o600))
+	catalog, err := LoadOutfitCatalog(path)
+	require.NoError(t, err)
+
+	storage := []*PlayerStorage{
+		{Key: 10001001, Value: 268<<16 | 3},
+		{Key: 10001002, Value: 269<<16 | 3},
+		{Key: 10001003, Value: 128<<16 | 1},
+		{Key: 10001004, Value: 999 << 16},
+		{Key: 50000, Value: 268 << 16},
+	}
+
+	outfits := catalog.PlayerOutfits(SexMale, storage)
+
+	require.Len(t, outfits, 3)
+	assert.Equal(t, 128, outfits[0].LookType)
+	assert.True(t, outfits[0].FirstAddon())
+	assert.False(t, outfits[0].SecondAddon())
+	assert.Equal(t, "Nightmare", outfits[1].Name)
+	assert.Equal(t, 3, outfits[1].Addons)
+	assert.True(t, outfits[1].SecondAddon())
+	assert.Equal(t, 999, outfits[2].LookType)
+	assert.Empty(t, outfits[2].Name)
+}
+
+func expectOutfitStorage(mock sqlmock.Sqlmock, playerID int, rows *sqlmock.Rows) {
+	mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? AND `key` BETWEEN \\? AND \\? FOR UPDATE").
+		WithArgs(playerID, OutfitStorageStart, OutfitStorageStart+OutfitStorageSize).
+		WillReturnRows(rows)
+}
+
+func TestPlayerStorageRepository_GrantOutfit(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("NewOutfit", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOutfitStorage(mock, 1, sqlmock.NewRows([]string{"player_id", "key", "value"}).
+			AddRow(1, 10001001, 128<<16))
+		mock.ExpectExec("INSERT INTO player_storage").
+			WithArgs(1, 10001002, 268<<16|1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.GrantOutfit(context.Background(), 1, 268, 1)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AddAddon", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOutfitStorage(mock, 1, sqlmock.NewRows([]string{"player_id", "key", "value"}).
+			AddRow(1, 10001001, 128<<16).
+			AddRow(1, 10001002, 268<<16|1))
+		mock.ExpectExec("INSERT INTO player_storage").
+			WithArgs(1, 10001002, 268<<16|3).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := repo.GrantOutfit(context.Background(), 1, 268, 2)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Online", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		err := repo.GrantOutfit(context.Background(), 1, 268, 0)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPlayerStorageRepository_RevokeOutfit(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("Addons", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOutfitStorage(mock, 1, sqlmock.NewRows([]string{"player_id", "key", "value"}).
+			AddRow(1, 10001002, 268<<16|3))
+		mock.ExpectExec("UPDATE player_storage SET value = \\? WHERE player_id = \\? AND `key` = \\?").
+			WithArgs(268<<16|1, 1, 10001002).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		addons := 2
+		revoked, err := repo.RevokeOutfit(context.Background(), 1, 268, &addons)
+
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Outfit", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOutfitStorage(mock, 1, sqlmock.NewRows([]string{"player_id", "key", "value"}).
+			AddRow(1, 10001002, 268<<16|3))
+		mock.ExpectExec("DELETE FROM player_storage WHERE player_id = \\? AND `key` = \\?").
+			WithArgs(1, 10001002).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeOutfit(context.Background(), 1, 268, nil)
+
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotUnlocked", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		expectOutfitStorage(mock, 1, sqlmock.NewRows([]string{"player_id", "key", "value"}))
+		mock.ExpectCommit()
+
+		revoked, err := repo.RevokeOutfit(context.Background(), 1, 268, nil)
+
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultSearchFirst = 20
+	maxSearchFirst     = 100
+
+	// Fuzzy matches are scored in Go, over at most this many candidates
+	maxSearchCandidates = 1000
+	// Names less similar than this to the query are dropped, as in pg_trgm
+	minSimilarity = 0.3
+	// Longer queries are refused; no account or character name is longer,
+	// and each extra character adds a trigram to match
+	maxSearchQueryLength = 32
+)
+
+var (
+	ErrInvalidSearch = fmt.Errorf("search query must be 1 to %d characters long", maxSearchQueryLength)
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// LevelRange limits a search to levels from Min to Max, inclusive. Either
+// bound may be nil.
+type LevelRange struct {
+	Min *int `json:"min"`
+	Max *int `json:"max"`
+}
+
+type PlayerSearch struct {
+	Query      string
+	Vocation   *int
+	LevelRange *LevelRange
+	Online     *bool
+	First      *int
+	After      *string
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type PlayerEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Player `json:"node"`
+	// Score is the trigram similarity between the query and the name
+	Score float64 `json:"score"`
+}
+
+type PlayerConnection struct {
+	Edges    []*PlayerEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+// Search finds characters by name. Names starting with the query come
+// first, shortest first, followed by names that are similar by trigram
+// similarity, most similar first. Matching is case-insensitive.
+func (r *PlayerRepository) Search(ctx context.Context, search PlayerSearch) (*PlayerConnection, error) {
+	q := strings.TrimSpace(search.Query)
+	if q == "" || len([]rune(q)) > maxSearchQueryLength {
+		return nil, ErrInvalidSearch
+	}
+
+	first := defaultSearchFirst
+	if search.First != nil {
+		first = min(max(*search.First, 0), maxSearchFirst)
+	}
+	offset := 0
+	if search.After != nil {
+		var err error
+		if offset, err = decodeCursor(*search.After); err != nil {
+			return nil, err
+		}
+	}
+
+	patterns := []string{escapeLike(q) + "%"}
+	for _, trigram := range substringTrigrams(strings.ToLower(q)) {
+		patterns = append(patterns, "%"+escapeLike(trigram)+"%")
+	}
+
+	matches := make([]string, len(patterns))
+	args := make([]interface{}, 0, len(patterns)+4)
+	for i, pattern := range patterns {
+		matches[i] = "p.name LIKE ?"
+		args = append(args, pattern)
+	}
+
+	query := `SELECT p.id, p.name, p.group_id, p.account_id, p.level, p.vocation, p.health, p.healthmax,
+	                 p.experience, p.lookbody, p.lookfeet, p.lookhead, p.looklegs, p.looktype, p.lookaddons,
+	                 p.maglevel, p.mana, p.manamax, p.soul, p.town_id, p.posx, p.posy, p.posz, p.cap, p.sex,
+	                 p.lastlogin, p.balance, p.deletion
+	          FROM players p
+	          WHERE (` + strings.Join(matches, " OR ") + `)`
+	if search.Vocation != nil {
+		query += " AND p.vocation = ?"
+		args = append(args, *search.Vocation)
+	}
+	if search.LevelRange != nil && search.LevelRange.Min != nil {
+		query += " AND p.level >= ?"
+		args = append(args, *search.LevelRange.Min)
+	}
+	if search.LevelRange != nil && search.LevelRange.Max != nil {
+		query += " AND p.level <= ?"
+		args = append(args, *search.LevelRange.Max)
+	}
+	if search.Online != nil {
+		online := "EXISTS (SELECT 1 FROM players_online o WHERE o.player_id = p.id)"
+		if !*search.Online {
+			online = "NOT " + online
+		}
+		query += " AND " + online
+	}
+	// The candidate limit keeps the best candidates: prefix matches first,
+	// then the names sharing the most trigrams with the query, shortest first
+	// as they have the fewest trigrams of their own
+	query += " ORDER BY p.name LIKE ? DESC"
+	args = append(args, patterns[0])
+	if len(patterns) > 1 {
+		query += ", (" + strings.Join(matches[1:], ") + (") + ") DESC"
+		for _, pattern := range patterns[1:] {
+			args = append(args, pattern)
+		}
+	}
+	query += ", CHAR_LENGTH(p.name), p.name LIMIT " + strconv.Itoa(maxSearchCandidates)
+
+	var candidates []*Player
+	if err := r.db.SelectContext(ctx, &candidates, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to search players: %w", err)
+	}
+
+	type hit struct {
+		player *Player
+		prefix bool
+		score  float64
+	}
+	lower := strings.ToLower(q)
+	var hits []hit
+	for _, player := range candidates {
+		name := strings.ToLower(player.Name)
+		h := hit{player: player, prefix: strings.HasPrefix(name, lower), score: similarity(lower, name)}
+		if h.prefix || h.score >= minSimilarity {
+			hits = append(hits, h)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.prefix && len(a.player.Name) != len(b.player.Name) {
+			return len(a.player.Name) < len(b.player.Name)
+		}
+		if !a.prefix && a.score != b.score {
+			return a.score > b.score
+		}
+		return a.player.Name < b.player.Name
+	})
+
+	connection := &PlayerConnection{Edges: []*PlayerEdge{}, PageInfo: &PageInfo{}}
+	for i := offset; i < len(hits) && len(connection.Edges) < first; i++ {
+		cursor := encodeCursor(i + 1)
+		connection.Edges = append(connection.Edges, &PlayerEdge{Cursor: cursor, Node: hits[i].player, Score: hits[i].score})
+		connection.PageInfo.EndCursor = &cursor
+	}
+	connection.PageInfo.HasNextPage = offset+len(connection.Edges) < len(hits)
+
+	return connection, nil
+}
+
+// encodeCursor returns an opaque cursor for the results after offset.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	value, ok := strings.CutPrefix(string(data), "offset:")
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// similarity is pg_trgm's similarity: the share of trigrams two strings
+// have in common, where each word is padded with two spaces in front and
+// one behind.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range ta {
+		if tb[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// substringTrigrams returns the distinct three-letter substrings of s, used
+// to fetch candidates that share at least one with the query.
+func substringTrigrams(s string) []string {
+	runes := []rune(s)
+	seen := make(map[string]bool)
+	var result []string
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			result = append(result, trigram)
+		}
+	}
+	return result
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
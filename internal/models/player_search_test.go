@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarity(t *testing.T) {
+	// The example from the pg_trgm documentation
+	assert.InDelta(t, 4.0/11.0, similarity("word", "two words"), 1e-9)
+	assert.Equal(t, 1.0, similarity("knight", "knight"))
+	assert.Zero(t, similarity("abc", ""))
+}
+
+func TestPlayerRepository_Search(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Sir Galahad").
+			AddRow(2, "Galahad").
+			AddRow(3, "Galahad the Pure").
+			AddRow(4, "Gala Dinner").
+			AddRow(5, "Bahadur")
+	}
+
+	t.Run("RanksPrefixThenSimilarity", func(t *testing.T) {
+		mock.ExpectQuery("FROM players p WHERE \\(p.name LIKE \\? OR p.name LIKE \\? OR .*\\) "+
+			"ORDER BY p.name LIKE \\? DESC, \\(p.name LIKE \\?\\) \\+ .* DESC, CHAR_LENGTH\\(p.name\\), p.name LIMIT 1000").
+			WithArgs("galahad%", "%gal%", "%ala%", "%lah%", "%aha%", "%had%",
+				"galahad%", "%gal%", "%ala%", "%lah%", "%aha%", "%had%").
+			WillReturnRows(rows())
+
+		result, err := repo.Search(context.Background(), PlayerSearch{Query: " galahad "})
+
+		require.NoError(t, err)
+		var names []string
+		for _, edge := range result.Edges {
+			names = append(names, edge.Node.Name)
+		}
+		assert.Equal(t, []string{"Galahad", "Galahad the Pure", "Sir Galahad"}, names)
+		assert.False(t, result.PageInfo.HasNextPage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RanksCandidatesBySharedTrigrams", func(t *testing.T) {
+		// "Zed Galahad" sorts last by name but shares every trigram with the
+		// query, so it must rank before names sharing fewer
+		mock.ExpectQuery("ORDER BY p.name LIKE \\? DESC, " +
+			"\\(p.name LIKE \\?\\) \\+ \\(p.name LIKE \\?\\) \\+ \\(p.name LIKE \\?\\) \\+ \\(p.name LIKE \\?\\) \\+ \\(p.name LIKE \\?\\) DESC, " +
+			"CHAR_LENGTH\\(p.name\\), p.name LIMIT 1000$").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+				AddRow(1, "Zed Galahad").
+				AddRow(2, "Aaa Gala"))
+
+		result, err := repo.Search(context.Background(), PlayerSearch{Query: "galahad"})
+
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Edges)
+		assert.Equal(t, "Zed Galahad", result.Edges[0].Node.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Paginates", func(t *testing.T) {
+		mock.ExpectQuery("FROM players p").WillReturnRows(rows())
+
+		first := 2
+		page, err := repo.Search(context.Background(), PlayerSearch{Query: "galahad", First: &first})
+		require.NoError(t, err)
+		require.Len(t, page.Edges, 2)
+		assert.True(t, page.PageInfo.HasNextPage)
+
+		mock.ExpectQuery("FROM players p").WillReturnRows(rows())
+
+		page, err = repo.Search(context.Background(), PlayerSearch{Query: "galahad", First: &first, After: page.PageInfo.EndCursor})
+		require.NoError(t, err)
+		require.Len(t, page.Edges, 1)
+		assert.Equal(t, "Sir Galahad", page.Edges[0].Node.Name)
+		assert.False(t, page.PageInfo.HasNextPage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		minLevel, maxLevel, vocation, online := 50, 100, 4, true
+		mock.ExpectQuery("WHERE \\(p.name LIKE \\?\\) AND p.vocation = \\? AND p.level >= \\? AND p.level <= \\? "+
+			"AND EXISTS \\(SELECT 1 FROM players_online o WHERE o.player_id = p.id\\) ORDER BY p.name LIKE \\? DESC, CHAR_LENGTH\\(p.name\\), p.name LIMIT 1000").
+			WithArgs("a\\_%", 4, 50, 100, "a\\_%").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "A_b"))
+
+		result, err := repo.Search(context.Background(), PlayerSearch{
+			Query:      "a_",
+			Vocation:   &vocation,
+			LevelRange: &LevelRange{Min: &minLevel, Max: &maxLevel},
+			Online:     &online,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Edges, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := repo.Search(context.Background(), PlayerSearch{Query: "  "})
+		assert.ErrorIs(t, err, ErrInvalidSearch)
+
+		_, err = repo.Search(context.Background(), PlayerSearch{Query: strings.Repeat("a", maxSearchQueryLength+1)})
+		assert.ErrorIs(t, err, ErrInvalidSearch)
+
+		after := "not-a-cursor"
+		_, err = repo.Search(context.Background(), PlayerSearch{Query: "galahad", After: &after})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestAccountRepository_Search(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewAccountRepository(db)
+
+	mock.ExpectQuery("SELECT id, name, password, secret, type, premium_ends_at, email, creation FROM accounts "+
+		"WHERE name LIKE \\? OR email LIKE \\? ORDER BY name LIKE \\? DESC, name LIMIT \\?").
+		WithArgs("%support%", "%support%", "support%", 20).
+		WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(1, "support", "", nil, 1, 0, "help@example.com", 0))
+
+	accounts, err := repo.Search(context.Background(), "support", 20)
+
+	require.NoError(t, err)
+	assert.Len(t, accounts, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
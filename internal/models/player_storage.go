@@ -3,8 +3,10 @@ package models
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+	"github.com/jmoiron/sqlx"
 )
 
 type PlayerStorage struct {
@@ -13,6 +15,37 @@ type PlayerStorage struct {
 	Value    int `db:"value" json:"value"`
 }
 
+// StorageRange selects the storage keys from From to To, inclusive.
+type StorageRange struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// MaxPlayersByStorage caps how many players GetByStorage returns.
+const MaxPlayersByStorage = 1000
+
+// StorageComparison is how PlayerRepository.GetByStorage compares a
+// storage value.
+type StorageComparison string
+
+const (
+	StorageComparisonEq  StorageComparison = "EQ"
+	StorageComparisonNe  StorageComparison = "NE"
+	StorageComparisonGt  StorageComparison = "GT"
+	StorageComparisonGte StorageComparison = "GTE"
+	StorageComparisonLt  StorageComparison = "LT"
+	StorageComparisonLte StorageComparison = "LTE"
+)
+
+var storageOperators = map[StorageComparison]string{
+	StorageComparisonEq:  "=",
+	StorageComparisonNe:  "<>",
+	StorageComparisonGt:  ">",
+	StorageComparisonGte: ">=",
+	StorageComparisonLt:  "<",
+	StorageComparisonLte: "<=",
+}
+
 type PlayerStorageRepository struct {
 	db *database.DB
 }
@@ -22,12 +55,118 @@ func NewPlayerStorageRepository(db *database.DB) *PlayerStorageRepository {
 }
 
 func (r *PlayerStorageRepository) GetByPlayerID(ctx context.Context, playerID int) ([]*PlayerStorage, error) {
-	var storage []*PlayerStorage
-	query := `SELECT player_id, key, value FROM player_storage WHERE player_id = ?`
+	return r.Find(ctx, playerID, nil, nil)
+}
+
+// Find returns a player's storage values. When keys or keyRange are given,
+// only the keys listed or inside the range are returned.
+func (r *PlayerStorageRepository) Find(ctx context.Context, playerID int, keys []int, keyRange *StorageRange) ([]*PlayerStorage, error) {
+	query := "SELECT player_id, `key`, value FROM player_storage WHERE player_id = ?"
+	args := []interface{}{playerID}
 
-	if err := r.db.SelectContext(ctx, &storage, query, playerID); err != nil {
+	var filters []string
+	if len(keys) > 0 {
+		filters = append(filters, "`key` IN (?)")
+		args = append(args, keys)
+	}
+	if keyRange != nil {
+		filters = append(filters, "`key` BETWEEN ? AND ?")
+		args = append(args, keyRange.From, keyRange.To)
+	}
+	if len(filters) > 0 {
+		query += " AND (" + strings.Join(filters, " OR ") + ")"
+	}
+	query += " ORDER BY `key`"
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build player storage query: %w", err)
+	}
+
+	var storage []*PlayerStorage
+	if err := r.db.SelectContext(ctx, &storage, r.db.Rebind(query), args...); err != nil {
 		return nil, fmt.Errorf("failed to get player storage: %w", err)
 	}
 
 	return storage, nil
 }
+
+// Set writes a storage value for a player, creating the key if needed.
+func (r *PlayerStorageRepository) Set(ctx context.Context, playerID, key, value int) (*PlayerStorage, error) {
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := "INSERT INTO player_storage (player_id, `key`, value) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value = VALUES(value)"
+		if _, err := tx.ExecContext(ctx, query, playerID, key, value); err != nil {
+			return fmt.Errorf("failed to set player storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlayerStorage{PlayerID: playerID, Key: key, Value: value}, nil
+}
+
+// Increment adds amount to a storage value, treating a missing key as 0.
+func (r *PlayerStorageRepository) Increment(ctx context.Context, playerID, key, amount int) (*PlayerStorage, error) {
+	storage := &PlayerStorage{PlayerID: playerID, Key: key}
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := "INSERT INTO player_storage (player_id, `key`, value) VALUES (?, ?, ?) " +
+			"ON DUPLICATE KEY UPDATE value = value + VALUES(value)"
+		if _, err := tx.ExecContext(ctx, query, playerID, key, amount); err != nil {
+			return fmt.Errorf("failed to increment player storage: %w", err)
+		}
+
+		query = "SELECT value FROM player_storage WHERE player_id = ? AND `key` = ?"
+		if err := tx.GetContext(ctx, &storage.Value, query, playerID, key); err != nil {
+			return fmt.Errorf("failed to get player storage: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Delete removes a storage key and reports whether it existed.
+func (r *PlayerStorageRepository) Delete(ctx context.Context, playerID, key int) (bool, error) {
+	var affected int64
+
+	err := r.db.Transaction(ctx, func(tx *sqlx.Tx) error {
+		if err := guardOffline(ctx, tx, playerID); err != nil {
+			return err
+		}
+
+		query := "DELETE FROM player_storage WHERE player_id = ? AND `key` = ?"
+		result, err := tx.ExecContext(ctx, query, playerID, key)
+		if err != nil {
+			return fmt.Errorf("failed to delete player storage: %w", err)
+		}
+
+		affected, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
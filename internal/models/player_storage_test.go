@@ -0,0 +1,187 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayerStorageRepository_Set(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("Offline", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		mock.ExpectExec("INSERT INTO player_storage (.+) ON DUPLICATE KEY UPDATE value = VALUES\\(value\\)").
+			WithArgs(1, 50000, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		storage, err := repo.Set(context.Background(), 1, 50000, 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, storage.Value)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Online", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		storage, err := repo.Set(context.Background(), 1, 50000, 3)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.Nil(t, storage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StaffOverride", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO player_storage").
+			WithArgs(1, 50000, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.Set(WithOnlineOverride(context.Background()), 1, 50000, 3)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPlayerStorageRepository_Find(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("All", func(t *testing.T) {
+		mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? ORDER BY `key`").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"player_id", "key", "value"}).
+				AddRow(1, 1000, 1).
+				AddRow(1, 50000, 3))
+
+		storage, err := repo.GetByPlayerID(context.Background(), 1)
+
+		require.NoError(t, err)
+		assert.Len(t, storage, 2)
+		assert.Equal(t, 50000, storage[1].Key)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("KeysAndRange", func(t *testing.T) {
+		mock.ExpectQuery("SELECT player_id, `key`, value FROM player_storage WHERE player_id = \\? "+
+			"AND \\(`key` IN \\(\\?, \\?\\) OR `key` BETWEEN \\? AND \\?\\) ORDER BY `key`").
+			WithArgs(1, 1000, 1001, 50000, 50100).
+			WillReturnRows(sqlmock.NewRows([]string{"player_id", "key", "value"}).AddRow(1, 1000, 1))
+
+		storage, err := repo.Find(context.Background(), 1, []int{1000, 1001}, &StorageRange{From: 50000, To: 50100})
+
+		require.NoError(t, err)
+		assert.Len(t, storage, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPlayerStorageRepository_Increment(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	mock.ExpectBegin()
+	expectOnlineCheck(mock, 1, false)
+	mock.ExpectExec("INSERT INTO player_storage (.+) ON DUPLICATE KEY UPDATE value = value \\+ VALUES\\(value\\)").
+		WithArgs(1, 50000, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT value FROM player_storage WHERE player_id = \\? AND `key` = \\?").
+		WithArgs(1, 50000).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(5))
+	mock.ExpectCommit()
+
+	storage, err := repo.Increment(context.Background(), 1, 50000, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, storage.Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerStorageRepository_Delete(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerStorageRepository(db)
+
+	t.Run("Deleted", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, false)
+		mock.ExpectExec("DELETE FROM player_storage WHERE player_id = \\? AND `key` = \\?").
+			WithArgs(1, 50000).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		deleted, err := repo.Delete(context.Background(), 1, 50000)
+
+		require.NoError(t, err)
+		assert.True(t, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Online", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOnlineCheck(mock, 1, true)
+		mock.ExpectRollback()
+
+		deleted, err := repo.Delete(context.Background(), 1, 50000)
+
+		assert.ErrorIs(t, err, ErrPlayerOnline)
+		assert.False(t, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPlayerRepository_GetByStorage(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	t.Run("GreaterOrEqual", func(t *testing.T) {
+		mock.ExpectQuery("FROM players p INNER JOIN player_storage s ON s.player_id = p.id WHERE s.`key` = \\? AND s.value >= \\? ORDER BY p.name LIMIT \\?").
+			WithArgs(50000, 3, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Quester"))
+
+		players, err := repo.GetByStorage(context.Background(), 50000, 3, StorageComparisonGte, 100)
+
+		require.NoError(t, err)
+		assert.Len(t, players, 1)
+		assert.Equal(t, "Quester", players[0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CapsLimit", func(t *testing.T) {
+		mock.ExpectQuery("LIMIT \\?").
+			WithArgs(50000, 0, MaxPlayersByStorage).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		_, err := repo.GetByStorage(context.Background(), 50000, 0, StorageComparisonGte, MaxPlayersByStorage+1)
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidComparison", func(t *testing.T) {
+		players, err := repo.GetByStorage(context.Background(), 50000, 3, "LIKE", 100)
+
+		assert.Error(t, err)
+		assert.Nil(t, players)
+	})
+}
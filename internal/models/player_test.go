@@ -142,9 +142,24 @@ func TestPlayerRepository_Create(t *testing.T) {
 		Vocation:  4,
 	}
 
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM accounts WHERE id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(input.AccountID))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players WHERE account_id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT id, name, posx, posy, posz FROM towns WHERE id = ?").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "posx", "posy", "posz"}).AddRow(1, "Thais", 32369, 32241, 7))
 	mock.ExpectExec("INSERT INTO players").
-		WithArgs(input.Name, input.AccountID, input.Sex, input.Vocation).
+		WithArgs(input.Name, input.AccountID, input.Sex, input.Vocation,
+			8, int64(4200), 185, 185, 90, 90, 470, 100,
+			128, 78, 69, 58, 76,
+			1, 32369, 32241, 7, 0,
+			10, 10, 10, 10, 10, 10, 10).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	// Mock the GetByID call that happens after insert
 	rows := sqlmock.NewRows([]string{
@@ -153,8 +168,8 @@ func TestPlayerRepository_Create(t *testing.T) {
 		"maglevel", "mana", "manamax", "soul", "town_id", "posx", "posy", "posz", "cap", "sex",
 		"lastlogin", "balance",
 	}).AddRow(
-		1, input.Name, 1, input.AccountID, 1, input.Vocation, 150, 150, 0,
-		0, 0, 0, 0, 136, 0, 0, 0, 0, 0, 1, 0, 0, 0, 400, input.Sex, 0, 0,
+		1, input.Name, 1, input.AccountID, 8, input.Vocation, 185, 185, 4200,
+		69, 76, 78, 58, 128, 0, 0, 90, 90, 100, 1, 32369, 32241, 7, 470, input.Sex, 0, 0,
 	)
 
 	mock.ExpectQuery("SELECT (.+) FROM players WHERE id = ?").
@@ -169,6 +184,7 @@ func TestPlayerRepository_Create(t *testing.T) {
 	assert.Equal(t, input.AccountID, player.AccountID)
 	assert.Equal(t, input.Sex, player.Sex)
 	assert.Equal(t, input.Vocation, player.Vocation)
+	assert.Equal(t, 32369, player.PosX)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -185,10 +201,11 @@ func TestPlayerRepository_Create_InvalidAccount(t *testing.T) {
 		Vocation:  4,
 	}
 
-	// Simulate foreign key constraint violation
-	mock.ExpectExec("INSERT INTO players").
-		WithArgs(input.Name, input.AccountID, input.Sex, input.Vocation).
-		WillReturnError(sql.ErrConnDone)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM accounts WHERE id = ?").
+		WithArgs(input.AccountID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
 
 	player, err := repo.Create(context.Background(), input)
 
@@ -196,3 +213,48 @@ func TestPlayerRepository_Create_InvalidAccount(t *testing.T) {
 	assert.Nil(t, player)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestPlayerRepository_Create_CharacterLimit(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+	creation := DefaultCharacterCreation()
+	creation.MaxPerAccount = 3
+	repo.SetCharacterCreation(creation)
+
+	input := CreatePlayerInput{
+		Name:      "NewPlayer",
+		AccountID: 1,
+		Sex:       0,
+		Vocation:  1,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM accounts WHERE id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(input.AccountID))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM players WHERE account_id = ?").
+		WithArgs(input.AccountID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	player, err := repo.Create(context.Background(), input)
+
+	assert.ErrorIs(t, err, ErrCharacterLimit)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlayerRepository_Create_UnknownVocation(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewPlayerRepository(db)
+
+	player, err := repo.Create(context.Background(), CreatePlayerInput{Name: "NewPlayer", AccountID: 1, Sex: 1, Vocation: 9})
+
+	assert.Error(t, err)
+	assert.Nil(t, player)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,234 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// QuestStatus is how far a player got in a quest or mission.
+type QuestStatus string
+
+const (
+	QuestStatusInProgress QuestStatus = "IN_PROGRESS"
+	QuestStatusCompleted  QuestStatus = "COMPLETED"
+)
+
+// Quest is a quest log entry from the server's data/XML/quests.xml. IDs are
+// assigned in file order starting at 1, as TFS does.
+type Quest struct {
+	ID                int             `json:"id"`
+	Name              string          `json:"name"`
+	StartStorageID    int             `json:"startStorageId"`
+	StartStorageValue int             `json:"startStorageValue"`
+	Missions          []*QuestMission `json:"missions"`
+}
+
+// QuestMission is one mission of a quest, tracked by a single storage key.
+type QuestMission struct {
+	Name           string `json:"name"`
+	StorageID      int    `json:"storageId"`
+	StartValue     int    `json:"startValue"`
+	EndValue       int    `json:"endValue"`
+	IgnoreEndValue bool   `json:"ignoreEndValue"`
+
+	// Description is shown for every state when set; |STATE| is replaced
+	// with the storage value
+	Description string
+	States      map[int]string
+}
+
+// PlayerQuest is a quest as it appears in a player's quest log.
+type PlayerQuest struct {
+	QuestID  int                   `json:"questId"`
+	Name     string                `json:"name"`
+	Status   QuestStatus           `json:"status"`
+	Missions []*PlayerQuestMission `json:"missions"`
+}
+
+// PlayerQuestMission is a started mission in a player's quest log.
+type PlayerQuestMission struct {
+	Name        string      `json:"name"`
+	Status      QuestStatus `json:"status"`
+	Description string      `json:"description"`
+	Value       int         `json:"value"`
+}
+
+// QuestCatalog holds the quests defined by the game server.
+type QuestCatalog struct {
+	Quests []*Quest
+}
+
+type questsXML struct {
+	Quests []struct {
+		Name              string `xml:"name,attr"`
+		StartStorageID    int    `xml:"startstorageid,attr"`
+		StartStorageValue int    `xml:"startstoragevalue,attr"`
+		Missions          []struct {
+			Name           string `xml:"name,attr"`
+			StorageID      int    `xml:"storageid,attr"`
+			StartValue     int    `xml:"startvalue,attr"`
+			EndValue       int    `xml:"endvalue,attr"`
+			IgnoreEndValue string `xml:"ignoreendvalue,attr"`
+			Description    string `xml:"description,attr"`
+			States         []struct {
+				ID          int    `xml:"id,attr"`
+				Description string `xml:"description,attr"`
+			} `xml:"missionstate"`
+		} `xml:"mission"`
+	} `xml:"quest"`
+}
+
+// LoadQuestCatalog reads a TFS quests.xml file.
+func LoadQuestCatalog(path string) (*QuestCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quests: %w", err)
+	}
+
+	var doc questsXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse quests: %w", err)
+	}
+
+	catalog := &QuestCatalog{}
+	for i, q := range doc.Quests {
+		quest := &Quest{
+			ID:                i + 1,
+			Name:              q.Name,
+			StartStorageID:    q.StartStorageID,
+			StartStorageValue: q.StartStorageValue,
+		}
+		for _, m := range q.Missions {
+			mission := &QuestMission{
+				Name:           m.Name,
+				StorageID:      m.StorageID,
+				StartValue:     m.StartValue,
+				EndValue:       m.EndValue,
+				IgnoreEndValue: xmlBool(m.IgnoreEndValue),
+				Description:    m.Description,
+				States:         make(map[int]string, len(m.States)),
+			}
+			for _, state := range m.States {
+				mission.States[state.ID] = state.Description
+			}
+			quest.Missions = append(quest.Missions, mission)
+		}
+		catalog.Quests = append(catalog.Quests, quest)
+	}
+
+	return catalog, nil
+}
+
+// StorageKeys returns every storage key the catalog reads.
+func (c *QuestCatalog) StorageKeys() []int {
+	seen := make(map[int]bool)
+	var keys []int
+	add := func(key int) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, quest := range c.Quests {
+		add(quest.StartStorageID)
+		for _, mission := range quest.Missions {
+			add(mission.StorageID)
+		}
+	}
+
+	return keys
+}
+
+// Evaluate builds a player's quest log from its storage values, keyed by
+// storage key. Like the in-game log, it only lists started quests and
+// started missions.
+func (c *QuestCatalog) Evaluate(storage map[int]int) []*PlayerQuest {
+	questLog := []*PlayerQuest{}
+
+	for _, quest := range c.Quests {
+		value, ok := storage[quest.StartStorageID]
+		if !ok || value < quest.StartStorageValue {
+			continue
+		}
+
+		entry := &PlayerQuest{
+			QuestID:  quest.ID,
+			Name:     quest.Name,
+			Status:   QuestStatusCompleted,
+			Missions: []*PlayerQuestMission{},
+		}
+		for _, mission := range quest.Missions {
+			value, ok := storage[mission.StorageID]
+			if !ok || !mission.isCompleted(value) {
+				entry.Status = QuestStatusInProgress
+			}
+			if !ok || !mission.isStarted(value) {
+				continue
+			}
+
+			status := QuestStatusInProgress
+			if mission.isCompleted(value) {
+				status = QuestStatusCompleted
+			}
+			entry.Missions = append(entry.Missions, &PlayerQuestMission{
+				Name:        mission.Name,
+				Status:      status,
+				Description: mission.describe(value),
+				Value:       value,
+			})
+		}
+
+		questLog = append(questLog, entry)
+	}
+
+	return questLog
+}
+
+func (m *QuestMission) isStarted(value int) bool {
+	if value < m.StartValue {
+		return false
+	}
+	return m.IgnoreEndValue || value <= m.EndValue
+}
+
+func (m *QuestMission) isCompleted(value int) bool {
+	if m.IgnoreEndValue {
+		return value >= m.EndValue
+	}
+	return value == m.EndValue
+}
+
+// describe picks the mission text the way the TFS quest log does: the
+// mission description if set, otherwise the highest state the value has
+// reached.
+func (m *QuestMission) describe(value int) string {
+	if m.Description != "" {
+		description := strings.ReplaceAll(m.Description, "|STATE|", strconv.Itoa(value))
+		return strings.ReplaceAll(description, "\\n", "\n")
+	}
+
+	for state := m.EndValue; state >= m.StartValue; state-- {
+		reached := value == state
+		if m.IgnoreEndValue {
+			reached = value >= state
+		}
+		if !reached {
+			continue
+		}
+		if description, ok := m.States[state]; ok {
+			return description
+		}
+	}
+
+	return "An error has occurred, please contact a gamemaster."
+}
+
+// xmlBool reads a boolean attribute the way pugixml's as_bool does, which
+// is what TFS uses for its XML files.
+func xmlBool(value string) bool {
+	return value != "" && strings.ContainsRune("1tTyY", rune(value[0]))
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testQuestsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<quests>
+	<quest name="Example Quest I" startstorageid="1001" startstoragevalue="1">
+		<mission name="Example Mission 1" storageid="1001" startvalue="1" endvalue="3">
+			<missionstate id="1" description="Example description 1" />
+			<missionstate id="2" description="Example description 2" />
+			<missionstate id="3" description="Example description 3" />
+		</mission>
+		<mission name="Example Mission 2" storageid="1002" startvalue="1" endvalue="5" description="Your current storage is |STATE|." />
+	</quest>
+	<quest name="Example Quest II" startstorageid="2001" startstoragevalue="1">
+		<mission name="Collector" storageid="2001" startvalue="1" endvalue="10" ignoreendvalue="true">
+			<missionstate id="1" description="Collect ten items." />
+			<missionstate id="10" description="You collected enough." />
+		</mission>
+	</quest>
+</quests>`
+
+func loadTestQuests(t *testing.T) *QuestCatalog {
+	path := filepath.Join(t.TempDir(), "quests.xml")
+	require.NoError(t, os.WriteFile(path, []byte(testQuestsXML), 0o600))
+
+	catalog, err := LoadQuestCatalog(path)
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestLoadQuestCatalog(t *testing.T) {
+	catalog := loadTestQuests(t)
+
+	require.Len(t, catalog.Quests, 2)
+	assert.Equal(t, 1, catalog.Quests[0].ID)
+	assert.Equal(t, 2, catalog.Quests[1].ID)
+	assert.Equal(t, 1001, catalog.Quests[0].StartStorageID)
+	require.Len(t, catalog.Quests[0].Missions, 2)
+	assert.Equal(t, "Example description 2", catalog.Quests[0].Missions[0].States[2])
+	assert.True(t, catalog.Quests[1].Missions[0].IgnoreEndValue)
+	assert.ElementsMatch(t, []int{1001, 1002, 2001}, catalog.StorageKeys())
+}
+
+func TestLoadQuestCatalog_Missing(t *testing.T) {
+	_, err := LoadQuestCatalog(filepath.Join(t.TempDir(), "missing.xml"))
+	assert.Error(t, err)
+}
+
+func TestQuestCatalog_Evaluate(t *testing.T) {
+	catalog := loadTestQuests(t)
+
+	t.Run("NotStarted", func(t *testing.T) {
+		assert.Empty(t, catalog.Evaluate(map[int]int{}))
+	})
+
+	t.Run("InProgress", func(t *testing.T) {
+		log := catalog.Evaluate(map[int]int{1001: 2})
+
+		require.Len(t, log, 1)
+		assert.Equal(t, "Example Quest I", log[0].Name)
+		assert.Equal(t, QuestStatusInProgress, log[0].Status)
+		require.Len(t, log[0].Missions, 1)
+		assert.Equal(t, QuestStatusInProgress, log[0].Missions[0].Status)
+		assert.Equal(t, "Example description 2", log[0].Missions[0].Description)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		log := catalog.Evaluate(map[int]int{1001: 3, 1002: 5, 2001: 12})
+
+		require.Len(t, log, 2)
+		assert.Equal(t, QuestStatusCompleted, log[0].Status)
+		assert.Equal(t, "Your current storage is 5.", log[0].Missions[1].Description)
+		assert.Equal(t, QuestStatusCompleted, log[1].Status)
+		assert.Equal(t, "You collected enough.", log[1].Missions[0].Description)
+	})
+
+	t.Run("PastEndValue", func(t *testing.T) {
+		log := catalog.Evaluate(map[int]int{1001: 4})
+
+		require.Len(t, log, 1)
+		assert.Empty(t, log[0].Missions)
+		assert.Equal(t, QuestStatusInProgress, log[0].Status)
+	})
+
+	t.Run("IgnoreEndValueState", func(t *testing.T) {
+		log := catalog.Evaluate(map[int]int{2001: 5})
+
+		require.Len(t, log, 1)
+		assert.Equal(t, "Collect ten items.", log[0].Missions[0].Description)
+	})
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/database"
+)
+
+// SupportedDBVersions are the server_config db_version values of the TFS
+// schemas the repositories are written against. TFS 1.4.2 migrates its
+// database to version 33.
+var SupportedDBVersions = []int{33}
+
+var ErrUnsupportedDBVersion = errors.New("unsupported database version")
+
+// ServerConfig holds the values TFS keeps in its server_config table.
+type ServerConfig struct {
+	DBVersion     int    `json:"dbVersion"`
+	MotdHash      string `json:"motdHash"`
+	MotdNum       int    `json:"motdNum"`
+	PlayersRecord int    `json:"playersRecord"`
+}
+
+type ServerConfigRepository struct {
+	db *database.DB
+}
+
+func NewServerConfigRepository(db *database.DB) *ServerConfigRepository {
+	return &ServerConfigRepository{db: db}
+}
+
+// Get reads server_config. Keys the game server hasn't written yet are left
+// at their zero value.
+func (r *ServerConfigRepository) Get(ctx context.Context) (*ServerConfig, error) {
+	var rows []struct {
+		Config string `db:"config"`
+		Value  string `db:"value"`
+	}
+	query := `SELECT config, value FROM server_config`
+	if err := r.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to get server config: %w", err)
+	}
+
+	config := &ServerConfig{}
+	for _, row := range rows {
+		var target *int
+		switch row.Config {
+		case "db_version":
+			target = &config.DBVersion
+		case "motd_num":
+			target = &config.MotdNum
+		case "players_record":
+			target = &config.PlayersRecord
+		case "motd_hash":
+			config.MotdHash = row.Value
+		}
+		if target == nil || row.Value == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(row.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server_config %s %q: %w", row.Config, row.Value, err)
+		}
+		*target = value
+	}
+
+	return config, nil
+}
+
+// CheckDBVersion returns ErrUnsupportedDBVersion unless the database's
+// db_version is one of SupportedDBVersions.
+func (r *ServerConfigRepository) CheckDBVersion(ctx context.Context) error {
+	config, err := r.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(SupportedDBVersions, config.DBVersion) {
+		return fmt.Errorf("%w: server_config db_version is %d, but this API supports %v; "+
+			"start the game server once to migrate its database, or upgrade the API",
+			ErrUnsupportedDBVersion, config.DBVersion, SupportedDBVersions)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConfigRepository_Get(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewServerConfigRepository(db)
+
+	mock.ExpectQuery("SELECT config, value FROM server_config").
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).
+			AddRow("db_version", "33").
+			AddRow("motd_hash", "e9fbd9a2").
+			AddRow("motd_num", "2").
+			AddRow("players_record", "57").
+			AddRow("something_else", "x"))
+
+	config, err := repo.Get(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &ServerConfig{DBVersion: 33, MotdHash: "e9fbd9a2", MotdNum: 2, PlayersRecord: 57}, config)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServerConfigRepository_Get_InvalidValue(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewServerConfigRepository(db)
+
+	mock.ExpectQuery("SELECT config, value FROM server_config").
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("players_record", "many"))
+
+	_, err := repo.Get(context.Background())
+
+	assert.ErrorContains(t, err, "players_record")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServerConfigRepository_CheckDBVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		wantErr bool
+	}{
+		{"Supported", sqlmock.NewRows([]string{"config", "value"}).AddRow("db_version", "33"), false},
+		{"Older", sqlmock.NewRows([]string{"config", "value"}).AddRow("db_version", "19"), true},
+		{"Missing", sqlmock.NewRows([]string{"config", "value"}), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT config, value FROM server_config").WillReturnRows(tt.rows)
+
+			err := NewServerConfigRepository(db).CheckDBVersion(context.Background())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedDBVersion)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestOnlineHistoryRepository_Record(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO api_online_history \\(sampled_at, players_online\\) VALUES \\(UNIX_TIMESTAMP\\(\\), \\?\\)").
+		WithArgs(12).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, NewOnlineHistoryRepository(db).Record(context.Background(), 12))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOnlineHistoryRepository_GetHistory(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	repo := NewOnlineHistoryRepository(db)
+
+	mock.ExpectQuery("FROM api_online_history WHERE sampled_at >= UNIX_TIMESTAMP\\(\\) - \\? ORDER BY sampled_at").
+		WithArgs(24 * 60 * 60).
+		WillReturnRows(sqlmock.NewRows([]string{"sampled_at", "players_online"}).
+			AddRow(1700000000, 10).
+			AddRow(1700000300, 12))
+
+	samples, err := repo.GetHistory(context.Background(), 24)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 12, samples[1].PlayersOnline)
+
+	_, err = repo.GetHistory(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+	_, err = repo.GetHistory(context.Background(), maxOnlineHistoryHours+1)
+	assert.ErrorIs(t, err, ErrInvalidHistoryRange)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOnlineHistoryRepository_DeleteBefore(t *testing.T) {
+	db, mock := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE FROM api_online_history WHERE sampled_at < \\?").
+		WithArgs(int64(1700000000)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := NewOnlineHistoryRepository(db).DeleteBefore(context.Background(), 1700000000)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,69 @@
+package persisted
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes, the first matching gqlgen's automatic persisted queries so
+// Apollo clients handle it the same way.
+const (
+	errNotFound   = "PERSISTED_QUERY_NOT_FOUND"
+	errNotAllowed = "OPERATION_NOT_ALLOWED"
+)
+
+// Allowlist is a gqlgen extension that only runs operations from a
+// manifest. Clients send either an operation's text or, as with automatic
+// persisted queries, just its ID or hash in
+// extensions.persistedQuery.sha256Hash. Staff requests may run anything.
+// It replaces the AutomaticPersistedQuery extension, which would let
+// clients register new operations.
+type Allowlist struct {
+	Manifest *Manifest
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = Allowlist{}
+
+func (Allowlist) ExtensionName() string {
+	return "Allowlist"
+}
+
+func (Allowlist) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a Allowlist) MutateOperationParameters(ctx context.Context, params *graphql.RawParams) *gqlerror.Error {
+	if params.Query == "" {
+		if id := persistedQueryID(params); id != "" {
+			query, ok := a.Manifest.Lookup(id)
+			if !ok {
+				err := gqlerror.Errorf("PersistedQueryNotFound")
+				errcode.Set(err, errNotFound)
+				return err
+			}
+			params.Query = query
+			return nil
+		}
+	}
+
+	if auth.IsStaff(ctx) || a.Manifest.Contains(params.Query) {
+		return nil
+	}
+	err := gqlerror.Errorf("operation is not in the allowlist")
+	errcode.Set(err, errNotAllowed)
+	return err
+}
+
+// persistedQueryID returns the hash sent in the persisted query extension.
+func persistedQueryID(params *graphql.RawParams) string {
+	ext, _ := params.Extensions["persistedQuery"].(map[string]any)
+	id, _ := ext["sha256Hash"].(string)
+	return id
+}
@@ -0,0 +1,103 @@
+package persisted
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestAllowlist(t *testing.T) {
+	m, err := LoadManifest(writeManifest(t, testApolloManifest))
+	require.NoError(t, err)
+
+	db, mock, err := models.NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(db)}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.Use(Allowlist{Manifest: m})
+
+	post := func(principal *auth.Principal, body string) response {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+
+		var resp response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+	expectTowns := func() {
+		mock.ExpectQuery("FROM towns").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "posx", "posy", "posz"}).AddRow(1, "Thais", 100, 100, 7))
+	}
+
+	t.Run("Text", func(t *testing.T) {
+		expectTowns()
+		resp := post(nil, `{"query":"query Towns { towns { id name } }"}`)
+		assert.Empty(t, resp.Errors)
+		assert.NotNil(t, resp.Data["towns"])
+	})
+
+	t.Run("ID", func(t *testing.T) {
+		expectTowns()
+		resp := post(nil, `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+hash(townsQuery)+`"}}}`)
+		assert.Empty(t, resp.Errors)
+		assert.NotNil(t, resp.Data["towns"])
+	})
+
+	t.Run("UnknownID", func(t *testing.T) {
+		resp := post(nil, `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"ffff"}}}`)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "PersistedQueryNotFound", resp.Errors[0].Message)
+		assert.Equal(t, "PERSISTED_QUERY_NOT_FOUND", resp.Errors[0].Extensions["code"])
+	})
+
+	t.Run("NotAllowed", func(t *testing.T) {
+		for _, body := range []string{
+			`{"query":"{ __schema { types { name } } }"}`,
+			`{"query":"query Towns { towns { id name } accounts { name } }"}`,
+			`{}`,
+		} {
+			resp := post(nil, body)
+			require.Len(t, resp.Errors, 1, body)
+			assert.Equal(t, "operation is not in the allowlist", resp.Errors[0].Message)
+			assert.Equal(t, "OPERATION_NOT_ALLOWED", resp.Errors[0].Extensions["code"])
+		}
+
+		resp := post(&auth.Principal{AccountID: 1}, `{"query":"{ towns { id } }"}`)
+		require.Len(t, resp.Errors, 1, "account sessions are restricted too")
+	})
+
+	t.Run("Staff", func(t *testing.T) {
+		expectTowns()
+		resp := post(&auth.Principal{Staff: true, Name: "alice"}, `{"query":"{ towns { id } }"}`)
+		assert.Empty(t, resp.Errors)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
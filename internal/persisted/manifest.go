@@ -0,0 +1,124 @@
+// Package persisted restricts the API to a manifest of known operations,
+// such as the persisted query manifest a frontend build generates.
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const apolloFormat = "apollo-persisted-query-manifest"
+
+// Manifest is a set of operations, each identified by the ID the frontend
+// build gave it and by the SHA-256 hash of its text.
+type Manifest struct {
+	byID   map[string]string
+	bodies map[string]bool
+	names  map[string]bool
+}
+
+// apolloManifest is the format written by Apollo's generate-persisted-query-manifest.
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// LoadManifest reads a manifest in Apollo's persisted query manifest format,
+// or a JSON object mapping IDs to operation text as written by Relay and
+// GraphQL Code Generator.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query manifest: %w", err)
+	}
+
+	m := &Manifest{byID: make(map[string]string), bodies: make(map[string]bool), names: make(map[string]bool)}
+
+	var apollo apolloManifest
+	if err := json.Unmarshal(data, &apollo); err == nil && apollo.Format == apolloFormat {
+		if apollo.Version != 1 {
+			return nil, fmt.Errorf("unsupported query manifest version %d", apollo.Version)
+		}
+		for _, op := range apollo.Operations {
+			m.add(op.ID, op.Body)
+		}
+	} else {
+		var documents map[string]string
+		if err := json.Unmarshal(data, &documents); err != nil {
+			return nil, fmt.Errorf("failed to parse query manifest: %w", err)
+		}
+		for id, body := range documents {
+			m.add(id, body)
+		}
+	}
+
+	if len(m.bodies) == 0 {
+		return nil, errors.New("query manifest has no operations")
+	}
+	return m, nil
+}
+
+func (m *Manifest) add(id, body string) {
+	if body == "" {
+		return
+	}
+	if id != "" {
+		m.byID[id] = body
+	}
+	m.byID[hash(body)] = body
+	m.bodies[body] = true
+
+	// Operations that don't parse are rejected when they are run
+	if doc, err := parser.ParseQuery(&ast.Source{Input: body}); err == nil {
+		for _, op := range doc.Operations {
+			if op.Name != "" {
+				m.names[op.Name] = true
+			}
+		}
+	}
+}
+
+// Lookup returns the operation with the given ID or hash.
+func (m *Manifest) Lookup(id string) (string, bool) {
+	body, ok := m.byID[id]
+	return body, ok
+}
+
+// Contains reports whether query is one of the manifest's operations,
+// character for character.
+func (m *Manifest) Contains(query string) bool {
+	return m.bodies[query]
+}
+
+// Len returns the number of operations.
+func (m *Manifest) Len() int {
+	return len(m.bodies)
+}
+
+// OperationNames returns the names of the manifest's operations, sorted.
+func (m *Manifest) OperationNames() []string {
+	names := make([]string, 0, len(m.names))
+	for name := range m.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
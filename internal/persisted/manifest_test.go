@@ -0,0 +1,77 @@
+package persisted
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	townsQuery  = "query Towns { towns { id name } }"
+	onlineQuery = "query Online { playersOnline { name level } }"
+)
+
+const testApolloManifest = `{
+  "format": "apollo-persisted-query-manifest",
+  "version": 1,
+  "operations": [
+    {"id": "ad3fa4ee25cc3fa8a7a9d3e1c5a8a1e5df4e1f2d7fc0a2b0a6b1ce55b4f4e3c2", "name": "Towns", "type": "query", "body": "query Towns { towns { id name } }"},
+    {"id": "online-1", "name": "Online", "type": "query", "body": "query Online { playersOnline { name level } }"}
+  ]
+}`
+
+func writeManifest(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadManifest_Apollo(t *testing.T) {
+	m, err := LoadManifest(writeManifest(t, testApolloManifest))
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Len())
+
+	body, ok := m.Lookup("online-1")
+	assert.True(t, ok)
+	assert.Equal(t, onlineQuery, body)
+
+	body, ok = m.Lookup(hash(onlineQuery))
+	assert.True(t, ok, "operations can also be looked up by hash")
+	assert.Equal(t, onlineQuery, body)
+
+	assert.True(t, m.Contains(townsQuery))
+	assert.False(t, m.Contains("query Towns { towns { id } }"))
+
+	assert.Equal(t, []string{"Online", "Towns"}, m.OperationNames())
+}
+
+func TestLoadManifest_Documents(t *testing.T) {
+	m, err := LoadManifest(writeManifest(t, `{"1a2b": "query Towns { towns { id name } }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Len())
+
+	body, ok := m.Lookup("1a2b")
+	assert.True(t, ok)
+	assert.Equal(t, townsQuery, body)
+	_, ok = m.Lookup("3c4d")
+	assert.False(t, ok)
+}
+
+func TestLoadManifest_Errors(t *testing.T) {
+	for name, content := range map[string]string{
+		"Invalid":  `[1, 2]`,
+		"Empty":    `{}`,
+		"Version2": `{"format": "apollo-persisted-query-manifest", "version": 2, "operations": []}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadManifest(writeManifest(t, content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+// Package ratelimit throttles clients with token buckets, keyed by account,
+// staff API key or client address, with separate limits per GraphQL
+// operation.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errRateLimited = "RATE_LIMITED"
+
+// rootTypes are the schema's root type names, for matching fragments spread
+// on the root selection set.
+var rootTypes = map[ast.Operation]string{
+	ast.Query:        "Query",
+	ast.Mutation:     "Mutation",
+	ast.Subscription: "Subscription",
+}
+
+// Limit allows Requests per Per, in bursts of up to Requests. The zero
+// Limit allows everything.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit parses a limit written as "10/1m", or "0" for none.
+func ParseLimit(s string) (Limit, error) {
+	if s == "0" {
+		return Limit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want requests/duration", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: invalid duration", s)
+	}
+	return Limit{Requests: n, Per: d}, nil
+}
+
+func (l Limit) String() string {
+	if l.Requests == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// rate is the number of tokens added per nanosecond.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / float64(l.Per)
+}
+
+// DefaultLimits are strict for account creation, login and recovery and
+// looser for everything else. Names are root fields, or "query" and
+// "mutation" for fields without their own limit.
+func DefaultLimits() map[string]Limit {
+	return map[string]Limit{
+		"query":                   {Requests: 300, Per: time.Minute},
+		"mutation":                {Requests: 60, Per: time.Minute},
+		"login":                   {Requests: 10, Per: time.Minute},
+		"createAccount":           {Requests: 3, Per: time.Hour},
+		"changePassword":          {Requests: 10, Per: time.Hour},
+		"requestPasswordReset":    {Requests: 5, Per: time.Hour},
+		"resetPassword":           {Requests: 10, Per: time.Hour},
+		"resendVerificationEmail": {Requests: 5, Per: time.Hour},
+		"verifyEmail":             {Requests: 10, Per: time.Hour},
+		"generateRecoveryKey":     {Requests: 5, Per: time.Hour},
+		"recoverAccount":          {Requests: 5, Per: time.Hour},
+	}
+}
+
+// ParseLimits applies entries written as "name=10/1m" over limits.
+func ParseLimits(limits map[string]Limit, entries []string) error {
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid rate limit %q: want name=requests/duration", entry)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return err
+		}
+		limits[name] = limit
+	}
+	return nil
+}
+
+// Error is returned when a client has used up a limit.
+type Error struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s, retry in %ds", e.Limit, e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as sent in
+// Retry-After headers.
+func (e *Error) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// Limiter applies limits to the caller of each request. It is also a gqlgen
+// extension charging every root field of an operation to the field's own
+// limit, or to the limit for its operation type, before the operation
+// runs.
+type Limiter struct {
+	store  Store
+	limits map[string]Limit
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = (*Limiter)(nil)
+
+func New(store Store, limits map[string]Limit) *Limiter {
+	return &Limiter{store: store, limits: limits}
+}
+
+// Allow takes a token from the caller's bucket for the named limit,
+// returning *Error when it is empty. Names without a limit are allowed. A
+// failing store is logged and lets the request through, so an outage of a
+// shared store doesn't take the API down with it.
+func (l *Limiter) Allow(ctx context.Context, name string) error {
+	if err := l.take(ctx, name); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *Limiter) take(ctx context.Context, name string) *Error {
+	limit := l.limits[name]
+	if limit.Requests == 0 {
+		return nil
+	}
+
+	ok, retryAfter, err := l.store.Take(ctx, name+"|"+clientKey(ctx), limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Rate limit check failed", "limit", name, "error", err)
+		return nil
+	}
+	if !ok {
+		return &Error{Limit: name, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// clientKey identifies the caller: their account, their staff API key, or
+// else the address they connect from. API keys are hashed so they don't end
+// up in a shared store.
+func clientKey(ctx context.Context) string {
+	if principal := auth.FromContext(ctx); principal != nil {
+		if principal.Staff {
+			sum := sha256.Sum256([]byte(principal.APIKey))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+		return "account:" + strconv.Itoa(principal.AccountID)
+	}
+	return "ip:" + auth.ClientIP(ctx)
+}
+
+func (l *Limiter) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (l *Limiter) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l *Limiter) MutateOperationContext(ctx context.Context, oc *graphql.OperationContext) *gqlerror.Error {
+	if oc.Operation == nil {
+		return nil
+	}
+
+	opType := string(oc.Operation.Operation)
+	for _, field := range graphql.CollectFields(oc, oc.Operation.SelectionSet, []string{rootTypes[oc.Operation.Operation]}) {
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+
+		name := field.Name
+		if _, ok := l.limits[name]; !ok {
+			name = opType
+		}
+		if err := l.take(ctx, name); err != nil {
+			return &gqlerror.Error{
+				Message: err.Error(),
+				Extensions: map[string]any{
+					"code":       errRateLimited,
+					"limit":      err.Limit,
+					"retryAfter": err.RetryAfterSeconds(),
+				},
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/auth"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits := DefaultLimits()
+	require.NoError(t, ParseLimits(limits, []string{"createAccount=1/24h", "query=0", "custom=5/30s"}))
+	assert.Equal(t, Limit{Requests: 1, Per: 24 * time.Hour}, limits["createAccount"])
+	assert.Equal(t, Limit{}, limits["query"])
+	assert.Equal(t, "5/30s", limits["custom"].String())
+	assert.Equal(t, Limit{Requests: 10, Per: time.Minute}, limits["login"], "other limits keep their defaults")
+
+	for _, entry := range []string{"login", "=1/1m", "login=10", "login=0/1m", "login=x/1m", "login=5/soon", "login=5/-1m"} {
+		assert.Error(t, ParseLimits(limits, []string{entry}), entry)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l := New(NewMemoryStore(), map[string]Limit{"login": {Requests: 1, Per: time.Minute}})
+	anonymous := context.Background()
+	account := auth.WithPrincipal(anonymous, &auth.Principal{AccountID: 1})
+	staff := auth.WithPrincipal(anonymous, &auth.Principal{Staff: true, APIKey: "k", Name: "alice"})
+
+	for _, ctx := range []context.Context{anonymous, account, staff} {
+		require.NoError(t, l.Allow(ctx, "login"), "callers have separate buckets")
+	}
+
+	var limitErr *Error
+	require.ErrorAs(t, l.Allow(account, "login"), &limitErr)
+	assert.Equal(t, "login", limitErr.Limit)
+	assert.Equal(t, 60, limitErr.RetryAfterSeconds())
+	assert.EqualError(t, limitErr, "rate limit exceeded for login, retry in 60s")
+
+	assert.NoError(t, l.Allow(account, "unlimited"), "names without a limit are allowed")
+	assert.NoError(t, New(failingStore{}, DefaultLimits()).Allow(anonymous, "login"), "a failing store lets requests through")
+}
+
+type response struct {
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func TestLimiter_Extension(t *testing.T) {
+	db, _, err := models.NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(db)}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.Use(New(NewMemoryStore(), map[string]Limit{
+		"query":    {Requests: 2, Per: time.Minute},
+		"mutation": {Requests: 100, Per: time.Minute},
+		"login":    {Requests: 2, Per: time.Minute},
+	}))
+
+	post := func(body string) response {
+		req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+
+		var resp response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	// Every root field takes a token, aliased or spread through a fragment.
+	resp := post(`{"query":"mutation { a: login(name: \"x\", password: \"y\") { token } ... on Mutation { b: login(name: \"x\", password: \"y\") { token } } }"}`)
+	for _, err := range resp.Errors {
+		assert.NotEqual(t, "RATE_LIMITED", err.Extensions["code"])
+	}
+
+	resp = post(`{"query":"mutation { login(name: \"x\", password: \"y\") { token } }"}`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "rate limit exceeded for login, retry in 30s", resp.Errors[0].Message)
+	assert.Equal(t, map[string]any{"code": "RATE_LIMITED", "limit": "login", "retryAfter": float64(30)}, resp.Errors[0].Extensions)
+
+	// Fields without their own limit share the operation type's.
+	post(`{"query":"{ __typename }"}`)
+	post(`{"query":"{ towns { id } }"}`)
+	post(`{"query":"{ a: towns { id } }"}`)
+	resp = post(`{"query":"{ towns { id } }"}`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "query", resp.Errors[0].Extensions["limit"])
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Per: time.Minute}
+	ctx := context.Background()
+
+	for range 2 {
+		ok, _, err := s.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, ok, "the bucket starts full")
+	}
+
+	ok, retryAfter, err := s.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter, "one token refills every 30s")
+
+	ok, _, _ = s.Take(ctx, "b", limit)
+	assert.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(20 * time.Second)
+	_, retryAfter, _ = s.Take(ctx, "a", limit)
+	assert.Equal(t, 10*time.Second, retryAfter)
+
+	now = now.Add(10 * time.Second)
+	ok, _, _ = s.Take(ctx, "a", limit)
+	assert.True(t, ok)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	s.Take(ctx, "short", Limit{Requests: 1, Per: time.Second})
+	s.Take(ctx, "long", Limit{Requests: 1, Per: time.Hour})
+	require.Len(t, s.buckets, 2)
+
+	now = now.Add(sweepInterval)
+	s.Take(ctx, "other", Limit{Requests: 5, Per: time.Hour})
+
+	assert.NotContains(t, s.buckets, "short", "refilled buckets are dropped")
+	assert.Contains(t, s.buckets, "long")
+	assert.Contains(t, s.buckets, "other")
+}
@@ -0,0 +1,99 @@
+// Package server runs the HTTP server and shuts it down gracefully,
+// websocket subscriptions included.
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeouts bound how long a connection may take to send a request, to be
+// written a response and to sit idle between requests. Zero means no limit.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+	Idle  time.Duration
+}
+
+// Server wraps http.Server so that Shutdown also ends websocket
+// connections, which http.Server.Shutdown stops tracking once they are
+// hijacked.
+type Server struct {
+	srv    *http.Server
+	cancel context.CancelFunc
+	active sync.WaitGroup
+}
+
+func New(addr string, handler http.Handler, timeouts Timeouts) *Server {
+	base, cancel := context.WithCancel(context.Background())
+	s := &Server{cancel: cancel}
+	s.srv = &http.Server{
+		Addr:              addr,
+		Handler:           s.track(handler),
+		ReadTimeout:       timeouts.Read,
+		ReadHeaderTimeout: timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+		BaseContext:       func(net.Listener) context.Context { return base },
+	}
+	return s
+}
+
+// track counts running handlers, which for a websocket last as long as the
+// connection.
+func (s *Server) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.active.Add(1)
+		defer s.active.Done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves on the configured address until Shutdown, when it
+// returns nil.
+func (s *Server) ListenAndServe() error {
+	return ignoreClosed(s.srv.ListenAndServe())
+}
+
+// Serve serves on l until Shutdown, when it returns nil.
+func (s *Server) Serve(l net.Listener) error {
+	return ignoreClosed(s.srv.Serve(l))
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish. It then cancels the context of every handler still running, which
+// closes websocket connections and their subscriptions, and waits for them
+// to return. If ctx ends first, remaining connections are closed and ctx's
+// error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if err := s.srv.Shutdown(ctx); err != nil {
+		s.cancel()
+		s.srv.Close()
+		return err
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func ignoreClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
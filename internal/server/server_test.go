@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func start(t *testing.T, handler http.Handler) (*Server, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := New("", handler, Timeouts{Read: time.Second, Write: time.Second, Idle: time.Second})
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+	t.Cleanup(func() {
+		s.srv.Close()
+		assert.NoError(t, <-served)
+	})
+	return s, l.Addr().String()
+}
+
+func TestShutdown_DrainsRequests(t *testing.T) {
+	started := make(chan struct{})
+	s, addr := start(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	}))
+
+	type result struct {
+		body string
+		err  error
+	}
+	got := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			got <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		got <- result{string(body), err}
+	}()
+
+	<-started
+	require.NoError(t, s.Shutdown(context.Background()))
+
+	res := <-got
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+
+	_, err := http.Get("http://" + addr)
+	assert.Error(t, err, "no new connections after shutdown")
+}
+
+// A hijacked connection stands in for a websocket: it is served until its
+// request context ends.
+func TestShutdown_EndsHijackedConnections(t *testing.T) {
+	s, addr := start(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("connected\n")
+		rw.Flush()
+
+		<-r.Context().Done()
+		rw.WriteString("terminated\n")
+		rw.Flush()
+	}))
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "connected\n", line)
+
+	require.NoError(t, s.Shutdown(context.Background()))
+
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "terminated\n", line)
+}
+
+func TestShutdown_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	s, addr := start(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go http.Get("http://" + addr)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Shutdown(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,266 @@
+// Package status queries a TFS game server over its status protocol, the
+// protocol 0xFF service listening on the login/status port.
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// The binary request flag for the player count and the reply's section
+// header, from TFS's ProtocolStatus.
+const (
+	requestPlayersInfo = 0x08
+	playersInfoReply   = 0x20
+)
+
+// maxReply bounds how much of a reply is read.
+const maxReply = 64 << 10
+
+var ErrInvalidReply = errors.New("invalid status reply")
+
+// Info is what the game server reports about itself in its XML status.
+type Info struct {
+	ServerName    string `json:"serverName"`
+	Location      string `json:"location"`
+	URL           string `json:"url"`
+	Software      string `json:"software"`
+	Version       string `json:"version"`
+	ClientVersion string `json:"clientVersion"`
+	Uptime        int64  `json:"uptime"`
+	PlayersOnline int    `json:"playersOnline"`
+	PlayersMax    int    `json:"playersMax"`
+	PlayersRecord int    `json:"playersRecord"`
+	MapName       string `json:"mapName"`
+	MapAuthor     string `json:"mapAuthor"`
+	Motd          string `json:"motd"`
+}
+
+// PlayerCount is the reply to the binary players request.
+type PlayerCount struct {
+	Online int `json:"online"`
+	Max    int `json:"max"`
+	Record int `json:"record"`
+}
+
+// Client talks to one game server's status port.
+type Client struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+func NewClient(addr string) *Client {
+	return &Client{Addr: addr, Timeout: 3 * time.Second}
+}
+
+type tsqp struct {
+	ServerInfo struct {
+		Uptime     int64  `xml:"uptime,attr"`
+		ServerName string `xml:"servername,attr"`
+		Location   string `xml:"location,attr"`
+		URL        string `xml:"url,attr"`
+		Server     string `xml:"server,attr"`
+		Version    string `xml:"version,attr"`
+		Client     string `xml:"client,attr"`
+	} `xml:"serverinfo"`
+	Players struct {
+		Online int `xml:"online,attr"`
+		Max    int `xml:"max,attr"`
+		Peak   int `xml:"peak,attr"`
+	} `xml:"players"`
+	Map struct {
+		Name   string `xml:"name,attr"`
+		Author string `xml:"author,attr"`
+	} `xml:"map"`
+	Motd string `xml:"motd"`
+}
+
+// Info sends the "info" request and parses the XML the server answers with.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var reply []byte
+	err := c.exchange(ctx, []byte{0xFF, 0xFF, 'i', 'n', 'f', 'o'}, func(conn net.Conn) error {
+		var err error
+		reply, err = io.ReadAll(io.LimitReader(conn, maxReply))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The document may follow a length header; start at the XML itself.
+	start := bytes.IndexByte(reply, '<')
+	if start < 0 {
+		return nil, ErrInvalidReply
+	}
+
+	var doc tsqp
+	if err := xml.Unmarshal(reply[start:], &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReply, err)
+	}
+
+	return &Info{
+		ServerName:    doc.ServerInfo.ServerName,
+		Location:      doc.ServerInfo.Location,
+		URL:           doc.ServerInfo.URL,
+		Software:      doc.ServerInfo.Server,
+		Version:       doc.ServerInfo.Version,
+		ClientVersion: doc.ServerInfo.Client,
+		Uptime:        doc.ServerInfo.Uptime,
+		PlayersOnline: doc.Players.Online,
+		PlayersMax:    doc.Players.Max,
+		PlayersRecord: doc.Players.Peak,
+		MapName:       doc.Map.Name,
+		MapAuthor:     doc.Map.Author,
+		Motd:          doc.Motd,
+	}, nil
+}
+
+// Players sends the binary info request for the player count only, a
+// smaller reply than the XML status.
+func (c *Client) Players(ctx context.Context) (*PlayerCount, error) {
+	request := []byte{0xFF, 0x01, 0, 0}
+	binary.LittleEndian.PutUint16(request[2:], requestPlayersInfo)
+
+	var payload []byte
+	err := c.exchange(ctx, request, func(conn net.Conn) error {
+		var header [2]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return err
+		}
+		payload = make([]byte, binary.LittleEndian.Uint16(header[:]))
+		_, err := io.ReadFull(conn, payload)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 13 || payload[0] != playersInfoReply {
+		return nil, ErrInvalidReply
+	}
+	return &PlayerCount{
+		Online: int(binary.LittleEndian.Uint32(payload[1:])),
+		Max:    int(binary.LittleEndian.Uint32(payload[5:])),
+		Record: int(binary.LittleEndian.Uint32(payload[9:])),
+	}, nil
+}
+
+// exchange sends request with its length header and lets read consume the
+// reply, all within the client's timeout.
+func (c *Client) exchange(ctx context.Context, request []byte, read func(net.Conn) error) error {
+	dialer := net.Dialer{Timeout: c.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to status server: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	packet := make([]byte, 2, 2+len(request))
+	binary.LittleEndian.PutUint16(packet, uint16(len(request)))
+	packet = append(packet, request...)
+	if _, err := conn.Write(packet); err != nil {
+		return fmt.Errorf("failed to send status request: %w", err)
+	}
+
+	if err := read(conn); err != nil {
+		return fmt.Errorf("failed to read status reply: %w", err)
+	}
+	return nil
+}
+
+// Status is the game server's status as last checked.
+type Status struct {
+	Online    bool  `json:"online"`
+	CheckedAt int64 `json:"checkedAt"`
+	Info      *Info `json:"info,omitempty"`
+}
+
+// PlayersOnline is the reported player count, or 0 while the server is
+// offline.
+func (s *Status) PlayersOnline() int {
+	if !s.Online || s.Info == nil {
+		return 0
+	}
+	return s.Info.PlayersOnline
+}
+
+// Monitor caches a server's status so clients polling it don't each open a
+// connection. TFS drops status requests from an address that asked less
+// than statusTimeout ago, so the TTL should be longer than that.
+type Monitor struct {
+	client *Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	status  *Status
+	checked time.Time
+	count   int
+	counted time.Time
+}
+
+func NewMonitor(client *Client, ttl time.Duration) *Monitor {
+	return &Monitor{client: client, ttl: ttl, now: time.Now}
+}
+
+// Status returns the cached status, checking the server again once it is
+// older than the TTL. A server that can't be reached is reported offline;
+// the error is returned alongside only when it was just checked.
+func (m *Monitor) Status(ctx context.Context) (*Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if m.status != nil && now.Sub(m.checked) < m.ttl {
+		return m.status, nil
+	}
+
+	info, err := m.client.Info(ctx)
+	status := &Status{Online: err == nil, CheckedAt: now.Unix(), Info: info}
+	// A request that gave up says nothing about the server.
+	if ctx.Err() == nil {
+		m.status, m.checked = status, now
+	}
+	return status, err
+}
+
+// PlayersOnline returns the number of players online. A fresh cached status
+// answers it; otherwise the server is sent the smaller players request, and
+// its answer is cached for the TTL too. A server that can't be reached
+// counts as 0, with the error returned only when it was just checked.
+func (m *Monitor) PlayersOnline(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if m.status != nil && now.Sub(m.checked) < m.ttl {
+		return m.status.PlayersOnline(), nil
+	}
+	if !m.counted.IsZero() && now.Sub(m.counted) < m.ttl {
+		return m.count, nil
+	}
+
+	count := 0
+	players, err := m.client.Players(ctx)
+	if err == nil {
+		count = players.Online
+	}
+	if ctx.Err() == nil {
+		m.count, m.counted = count, now
+	}
+	return count, err
+}
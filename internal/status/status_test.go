@@ -0,0 +1,192 @@
+package status
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const infoXML = `<?xml version="1.0"?>
+<tsqp version="1.0">
+<serverinfo uptime="3725" ip="127.0.0.1" servername="Forgotten" port="7171" location="Europe" url="https://example.com" server="The Forgotten Server" version="1.4.2" client="10.98"/>
+<owner name="" email=""/>
+<players online="12" max="1000" peak="57"/>
+<monsters total="1342"/>
+<npcs total="44"/>
+<rates experience="5" skill="3" loot="2" magic="3" spawn="1"/>
+<map name="forgotten" author="Komic" width="2048" height="2048"/>
+<motd>Welcome to the Forgotten Server!</motd>
+</tsqp>`
+
+// fakeServer answers status requests like TFS's ProtocolStatus, recording
+// each request packet it gets.
+type fakeServer struct {
+	addr     string
+	requests chan []byte
+	count    atomic.Int32
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeServer{addr: ln.Addr().String(), requests: make(chan []byte, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.count.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var header [2]byte
+	if _, err := io.ReadFull(conn, header[:]); err != nil {
+		return
+	}
+	packet := make([]byte, binary.LittleEndian.Uint16(header[:]))
+	if _, err := io.ReadFull(conn, packet); err != nil {
+		return
+	}
+	s.requests <- packet
+
+	switch {
+	case string(packet) == "\xff\xffinfo":
+		conn.Write([]byte(infoXML))
+	case len(packet) == 4 && packet[1] == 0x01:
+		reply := []byte{playersInfoReply}
+		for _, v := range []uint32{12, 1000, 57} {
+			reply = binary.LittleEndian.AppendUint32(reply, v)
+		}
+		conn.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(reply))))
+		conn.Write(reply)
+	}
+}
+
+func TestClient_Info(t *testing.T) {
+	server := newFakeServer(t)
+
+	info, err := NewClient(server.addr).Info(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{0xFF, 0xFF, 'i', 'n', 'f', 'o'}, <-server.requests)
+	assert.Equal(t, &Info{
+		ServerName:    "Forgotten",
+		Location:      "Europe",
+		URL:           "https://example.com",
+		Software:      "The Forgotten Server",
+		Version:       "1.4.2",
+		ClientVersion: "10.98",
+		Uptime:        3725,
+		PlayersOnline: 12,
+		PlayersMax:    1000,
+		PlayersRecord: 57,
+		MapName:       "forgotten",
+		MapAuthor:     "Komic",
+		Motd:          "Welcome to the Forgotten Server!",
+	}, info)
+}
+
+func TestClient_Players(t *testing.T) {
+	server := newFakeServer(t)
+
+	count, err := NewClient(server.addr).Players(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{0xFF, 0x01, 0x08, 0x00}, <-server.requests)
+	assert.Equal(t, &PlayerCount{Online: 12, Max: 1000, Record: 57}, count)
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	_, err = NewClient(addr).Info(context.Background())
+	assert.Error(t, err)
+}
+
+func TestMonitor_Status(t *testing.T) {
+	server := newFakeServer(t)
+
+	now := time.Unix(1700000000, 0)
+	monitor := NewMonitor(NewClient(server.addr), 30*time.Second)
+	monitor.now = func() time.Time { return now }
+
+	status, err := monitor.Status(context.Background())
+	require.NoError(t, err)
+	assert.True(t, status.Online)
+	assert.Equal(t, now.Unix(), status.CheckedAt)
+	assert.Equal(t, 12, status.Info.PlayersOnline)
+
+	now = now.Add(10 * time.Second)
+	_, err = monitor.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.count.Load(), "a fresh status is served from the cache")
+
+	now = now.Add(30 * time.Second)
+	status, err = monitor.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.count.Load())
+	assert.Equal(t, now.Unix(), status.CheckedAt)
+}
+
+func TestMonitor_PlayersOnline(t *testing.T) {
+	server := newFakeServer(t)
+
+	now := time.Unix(1700000000, 0)
+	monitor := NewMonitor(NewClient(server.addr), 30*time.Second)
+	monitor.now = func() time.Time { return now }
+
+	online, err := monitor.PlayersOnline(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 12, online)
+	assert.Equal(t, []byte{0xFF, 0x01, 0x08, 0x00}, <-server.requests, "only the count is asked for")
+
+	now = now.Add(10 * time.Second)
+	_, err = monitor.PlayersOnline(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), server.count.Load(), "a fresh count is served from the cache")
+
+	now = now.Add(30 * time.Second)
+	_, err = monitor.Status(context.Background())
+	require.NoError(t, err)
+	online, err = monitor.PlayersOnline(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 12, online)
+	assert.Equal(t, int32(2), server.count.Load(), "a fresh status answers the count")
+}
+
+func TestMonitor_Offline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	monitor := NewMonitor(NewClient(addr), 30*time.Second)
+
+	status, err := monitor.Status(context.Background())
+	assert.Error(t, err)
+	assert.False(t, status.Online)
+	assert.Nil(t, status.Info)
+
+	status, err = monitor.Status(context.Background())
+	assert.NoError(t, err, "the cached offline status is returned without an error")
+	assert.False(t, status.Online)
+}
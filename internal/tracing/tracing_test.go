@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/graph"
+	"github.com/glinharesb/forgottenserver-graphql-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanNames(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), ExporterNone, "", 1)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), "jaeger", "", 1)
+	assert.ErrorContains(t, err, `unknown tracing exporter "jaeger"`)
+}
+
+func TestTracer(t *testing.T) {
+	recorder := recordSpans(t)
+
+	db, mock, err := models.NewMockDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(db)}))
+	srv.AddTransport(transport.POST{})
+	srv.SetErrorPresenter(graph.ErrorPresenter)
+	srv.Use(Tracer{})
+	h := Middleware(srv)
+
+	mock.ExpectQuery("SELECT config, value FROM server_config").
+		WillReturnRows(sqlmock.NewRows([]string{"config", "value"}).AddRow("db_version", "33"))
+
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(`{"query":"query Info { serverInfo { dbVersion } onlineHistory(hours: 0) { sampledAt } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := spanNames(recorder)
+	require.Contains(t, spans, "POST /query")
+	require.Contains(t, spans, "query Info")
+	require.Contains(t, spans, "Query.serverInfo")
+	require.Contains(t, spans, "Query.onlineHistory")
+	assert.NotContains(t, spans, "ServerInfo.dbVersion", "struct fields are not traced")
+
+	httpSpan, operation := spans["POST /query"], spans["query Info"]
+	assert.Equal(t, httpSpan.SpanContext().SpanID(), operation.Parent().SpanID())
+	assert.Equal(t, operation.SpanContext().SpanID(), spans["Query.serverInfo"].Parent().SpanID())
+	assert.Equal(t, codes.Error, operation.Status().Code)
+	assert.Equal(t, codes.Error, spans["Query.onlineHistory"].Status().Code)
+	assert.Equal(t, codes.Unset, spans["Query.serverInfo"].Status().Code)
+}